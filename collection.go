@@ -0,0 +1,173 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"reflect"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// The default collection types of Collection.
+const (
+	ODCINumberList   = "SYS.ODCINUMBERLIST"
+	ODCIVarchar2List = "SYS.ODCIVARCHAR2LIST"
+	ODCIDateList     = "SYS.ODCIDATELIST"
+)
+
+// SQLCollection is a Go slice to be bound as a SQL collection (nested table or VARRAY),
+// such as for
+//
+//	SELECT * FROM tbl WHERE id IN (SELECT column_value FROM TABLE(:1))
+//
+// with one bind variable, whatever the number of elements is.
+//
+// The collection object is created when the statement is executed, and released after binding.
+type SQLCollection struct {
+	// TypeName is the name of the collection type; if empty,
+	// ODCINumberList is used for numbers, ODCIVarchar2List for strings and ODCIDateList for time.Time.
+	TypeName string
+	// Values is the slice of the elements. A nil pointer element is NULL.
+	Values interface{}
+}
+
+// Collection returns the slice to be bound as a collection of the given type
+// (the default for the element type, if typeName is empty).
+func Collection(typeName string, values interface{}) SQLCollection {
+	return SQLCollection{TypeName: typeName, Values: values}
+}
+
+// InList returns the slice to be bound as a collection of the default type,
+// for WHERE x IN (SELECT column_value FROM TABLE(:1)).
+func InList(values interface{}) SQLCollection {
+	return SQLCollection{Values: values}
+}
+
+// typeName returns the collection type name, the default for the element type if TypeName is empty.
+func (sc SQLCollection) typeName() (string, error) {
+	rv := reflect.ValueOf(sc.Values)
+	if rv.Kind() != reflect.Slice {
+		return "", errors.Errorf("collection of %T: %w", sc.Values, ErrNotSupported)
+	}
+	if sc.TypeName != "" {
+		return sc.TypeName, nil
+	}
+	et := rv.Type().Elem()
+	for et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+	switch et {
+	case reflect.TypeOf(time.Time{}):
+		return ODCIDateList, nil
+	case reflect.TypeOf(Number("")):
+		return ODCINumberList, nil
+	}
+	switch et.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return ODCINumberList, nil
+	case reflect.String:
+		return ODCIVarchar2List, nil
+	}
+	return "", errors.Errorf("no default collection type for %s: %w", et, ErrNotSupported)
+}
+
+// elements returns the values of the slice converted to what Data.Set accepts,
+// nil for the NULLs.
+func (sc SQLCollection) elements() ([]interface{}, error) {
+	rv := reflect.ValueOf(sc.Values)
+	if rv.Kind() != reflect.Slice {
+		return nil, errors.Errorf("collection of %T: %w", sc.Values, ErrNotSupported)
+	}
+	elts := make([]interface{}, rv.Len())
+	for i := range elts {
+		var err error
+		if elts[i], err = collectionElement(rv.Index(i)); err != nil {
+			return elts, errors.Errorf("%d. element: %w", i, err)
+		}
+	}
+	return elts, nil
+}
+
+func collectionElement(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return collectionElement(v.Elem())
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x, nil
+	case Number:
+		return string(x), nil
+	case []byte:
+		return x, nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+	return nil, errors.Errorf("%s: %w", v.Type(), ErrNotSupported)
+}
+
+// newCollection returns a new collection object with the values.
+//
+// As with all Objects, you MUST call Close on it when not needed anymore!
+func (sc SQLCollection) newCollection(c *conn) (*ObjectCollection, error) {
+	name, err := sc.typeName()
+	if err != nil {
+		return nil, err
+	}
+	elts, err := sc.elements()
+	if err != nil {
+		return nil, err
+	}
+	typ, err := c.GetObjectType(name)
+	if err != nil {
+		return nil, err
+	}
+	coll, err := typ.NewCollection()
+	if err != nil {
+		return nil, errors.Errorf("%s: %w", name, err)
+	}
+	var null Data
+	for i, e := range elts {
+		if e == nil {
+			null.reset()
+			null.NativeTypeNum = typ.CollectionOf.NativeTypeNum
+			err = coll.AppendData(&null)
+		} else {
+			err = coll.Append(e)
+		}
+		if err != nil {
+			coll.Close()
+			return nil, errors.Errorf("%s: %d. element: %w", name, i, err)
+		}
+	}
+	return coll, nil
+}
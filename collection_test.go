@@ -0,0 +1,69 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSQLCollection(t *testing.T) {
+	now := time.Date(2019, 10, 18, 12, 0, 0, 0, time.UTC)
+	one, s := 1, "b"
+	for _, tc := range []struct {
+		Coll     SQLCollection
+		TypeName string
+		Elements []interface{}
+	}{
+		{InList([]int64{3, 1, 2}), ODCINumberList, []interface{}{int64(3), int64(1), int64(2)}},
+		{InList([]uint8{7}), ODCINumberList, []interface{}{uint64(7)}},
+		{InList([]float64{0.5}), ODCINumberList, []interface{}{0.5}},
+		{InList([]Number{"1.25"}), ODCINumberList, []interface{}{"1.25"}},
+		{InList([]*int{&one, nil}), ODCINumberList, []interface{}{int64(1), nil}},
+		{InList([]string{"a", "b"}), ODCIVarchar2List, []interface{}{"a", "b"}},
+		{InList([]*string{nil, &s}), ODCIVarchar2List, []interface{}{nil, "b"}},
+		{InList([]time.Time{now}), ODCIDateList, []interface{}{now}},
+		{InList([]interface{}{1, "a", nil}), "", []interface{}{int64(1), "a", nil}},
+		{Collection("my_list", []int32{}), "my_list", []interface{}{}},
+		{Collection("raws", [][]byte{{1}}), "raws", []interface{}{[]byte{1}}},
+	} {
+		name, err := tc.Coll.typeName()
+		if tc.TypeName == "" {
+			if err == nil {
+				t.Errorf("%#v: got type %q", tc.Coll.Values, name)
+			}
+		} else if err != nil || name != tc.TypeName {
+			t.Errorf("%#v: got type %q (%v), wanted %q", tc.Coll.Values, name, err, tc.TypeName)
+		}
+		elts, err := tc.Coll.elements()
+		if err != nil {
+			t.Fatalf("%#v: %+v", tc.Coll.Values, err)
+		}
+		if !reflect.DeepEqual(elts, tc.Elements) {
+			t.Errorf("%#v: got %#v, wanted %#v", tc.Coll.Values, elts, tc.Elements)
+		}
+	}
+
+	for _, v := range []interface{}{1, nil, []struct{}{{}}, []complex64{1}} {
+		if _, err := InList(v).typeName(); err == nil {
+			t.Errorf("%#v: got type name", v)
+		}
+	}
+	if _, err := Collection("x", []struct{}{{}}).elements(); err == nil {
+		t.Error("got elements of struct")
+	}
+}
@@ -22,10 +22,13 @@ package goracle
 import "C"
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"database/sql/driver"
 	"io"
+	"math"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -57,7 +60,17 @@ type conn struct {
 	Client, Server VersionInfo
 	tranParams     tranParams
 	sync.RWMutex
-	currentUser string
+	// currentUser and currentPassword are what the session has been acquired with,
+	// to keep the user when only the session params change.
+	currentUser, currentPassword string
+	// session is what the current session has been acquired with,
+	// sessionTag is the actual tag of the acquired session.
+	session      sessionParams
+	sessionTag   string
+	sessionFixup SessionFixupFunc
+	// retag is true iff the session's state matches session.Tag:
+	// the tag has matched, or the session fixup succeeded.
+	retag bool
 	*drv
 	dpiConn       *C.dpiConn
 	inTransaction bool
@@ -65,6 +78,13 @@ type conn struct {
 	timeZone      *time.Location
 	tzOffSecs     int
 	objTypes      map[string]ObjectType
+	// tzRegions is true if the session's NLS_TIMESTAMP_TZ_FORMAT is set for TimeZoneRegions,
+	// and tzFormat is the original format, to be restored on close.
+	tzRegions bool
+	tzFormat  string
+	// dbCharset and dbNCharset are the cached database character sets, see EncodingInfo;
+	// guarded by the conn's RWMutex.
+	dbCharset, dbNCharset string
 }
 
 func (c *conn) getError() error {
@@ -155,6 +175,15 @@ func (c *conn) close(doNotReuse bool) error {
 		return nil
 	}
 	c.setTraceTag(TraceTag{})
+	if c.tzRegions && !doNotReuse && c.dpiConn != nil {
+		if err := c.resetTZRegions(); err != nil {
+			// do not give the session with the changed NLS_TIMESTAMP_TZ_FORMAT back to the pool
+			if Log != nil {
+				Log("msg", "resetTZRegions", "error", err)
+			}
+			doNotReuse = true
+		}
+	}
 	dpiConn, objTypes := c.dpiConn, c.objTypes
 	c.dpiConn, c.objTypes = nil, nil
 	if dpiConn == nil {
@@ -178,6 +207,14 @@ func (c *conn) close(doNotReuse bool) error {
 	var rc C.int
 	if doNotReuse {
 		rc = C.dpiConn_close(dpiConn, C.DPI_MODE_CONN_CLOSE_DROP, nil, 0)
+	} else if tag := c.session.Tag; tag != "" && c.retag {
+		cTag := C.CString(tag)
+		// close releases the session back to the pool with the new tag, release frees the handle.
+		rc = C.dpiConn_close(dpiConn, C.DPI_MODE_CONN_CLOSE_RETAG, cTag, C.uint32_t(len(tag)))
+		C.free(unsafe.Pointer(cTag))
+		if rc != C.DPI_FAILURE {
+			rc = C.dpiConn_release(dpiConn)
+		}
 	} else {
 		rc = C.dpiConn_release(dpiConn)
 	}
@@ -298,7 +335,7 @@ func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, e
 		if Log != nil {
 			Log("msg", "PrepareContext", "shortcut", query)
 		}
-		return &statement{conn: c, query: query}, nil
+		return &statement{conn: c, query: query, stmtOptions: c.stmtOptions()}, nil
 	}
 
 	cSQL := C.CString(query)
@@ -313,7 +350,12 @@ func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, e
 	) == C.DPI_FAILURE {
 		return nil, maybeBadConn(errors.Errorf("Prepare: %s: %w", query, c.getError()), c)
 	}
-	return &statement{conn: c, dpiStmt: dpiStmt, query: query}, nil
+	return &statement{conn: c, dpiStmt: dpiStmt, query: query, stmtOptions: c.stmtOptions()}, nil
+}
+
+// stmtOptions returns the default statement options of the connection.
+func (c *conn) stmtOptions() stmtOptions {
+	return stmtOptions{nullAsNil: c.connParams.NullAsNil, nativeNumbers: c.connParams.NativeNumbers}
 }
 func (c *conn) Commit() error {
 	return c.endTran(true)
@@ -698,17 +740,296 @@ func ContextWithUserPassw(ctx context.Context, user, password string) context.Co
 	return context.WithValue(ctx, userpwCtxKey, [2]string{user, password})
 }
 
-func (c *conn) ensureContextUser(ctx context.Context) error {
-	if !c.connParams.HeterogeneousPool {
+const proxyUserCtxKey = ctxKey("proxyUser")
+
+// ContextWithProxyUser returns a context with the specified end user,
+// to be used with proxy authentication ("appuser[enduser]") on the sessions of the pool.
+//
+// No password is needed, the session is authenticated through the pool's user.
+// As ODPI-C does not allow proxy authentication on acquire from homogeneous pools,
+// switching to another end user than the proxyUser parameter needs heterogeneousPool=1
+// in the connection string.
+func ContextWithProxyUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, proxyUserCtxKey, user)
+}
+
+const (
+	purityCtxKey    = ctxKey("purity")
+	connClassCtxKey = ctxKey("connClass")
+)
+
+// ContextWithPurity returns a context with the specified DRCP purity,
+// to be used when acquiring a session from the pool.
+//
+// PurityNew is for batch jobs that must not inherit any session state,
+// PuritySelf is for OLTP, reusing the sessions as is.
+func ContextWithPurity(ctx context.Context, purity Purity) context.Context {
+	return context.WithValue(ctx, purityCtxKey, purity)
+}
+
+// ContextWithConnClass returns a context with the specified connection class,
+// to be used when acquiring a session from the pool.
+func ContextWithConnClass(ctx context.Context, connClass string) context.Context {
+	return context.WithValue(ctx, connClassCtxKey, connClass)
+}
+
+const sessionTagCtxKey = ctxKey("sessionTag")
+
+type sessionTag struct {
+	Tag      string
+	MatchAny bool
+}
+
+// ContextWithSessionTag returns a context with the specified session tag,
+// to be requested when acquiring a session from the pool.
+//
+// With matchAny, a session with a different tag (or without a tag) may be returned
+// if no session with the requested tag is available.
+// The session fixup function (see WithSessionFixup) is called when the tags differ,
+// and the session is retagged with the requested tag on release -
+// iff it had the requested tag, or the fixup succeeded.
+func ContextWithSessionTag(ctx context.Context, tag string, matchAny bool) context.Context {
+	return context.WithValue(ctx, sessionTagCtxKey, sessionTag{Tag: tag, MatchAny: matchAny})
+}
+
+// SessionFixupFunc is called with the requested and the actual tag of the session
+// acquired from the pool, when they differ.
+// It should set the session state to match the requested tag.
+type SessionFixupFunc func(ctx context.Context, conn driver.Conn, requestedTag, actualTag string) error
+
+const timeZoneCtxKey = ctxKey("timeZone")
+
+// ContextWithTimeZone returns a context with the specified time zone,
+// to be used instead of the session's (computed once, for the first connection of the pool)
+// for the DATE and TIMESTAMP (without time zone) values of the query - both the bound and the fetched ones.
+//
+// It does not change the TIME_ZONE of the database session.
+func ContextWithTimeZone(ctx context.Context, tz *time.Location) context.Context {
+	return context.WithValue(ctx, timeZoneCtxKey, tz)
+}
+
+const (
+	shardingKeyCtxKey      = ctxKey("shardingKey")
+	superShardingKeyCtxKey = ctxKey("superShardingKey")
+)
+
+// ContextWithShardingKey returns a context with the specified sharding key,
+// to route the session acquired from the pool (or the standalone connection) to the right shard.
+//
+// The key columns can be string, []byte, int64 (or any other int), uint64, float64,
+// Number or time.Time.
+func ContextWithShardingKey(ctx context.Context, key ...interface{}) context.Context {
+	return context.WithValue(ctx, shardingKeyCtxKey, key)
+}
+
+// ContextWithSuperShardingKey returns a context with the specified super sharding key,
+// with the same possible column types as in ContextWithShardingKey.
+func ContextWithSuperShardingKey(ctx context.Context, key ...interface{}) context.Context {
+	return context.WithValue(ctx, superShardingKeyCtxKey, key)
+}
+
+// sessionParams are the parameters a pooled session is acquired with.
+type sessionParams struct {
+	ConnClass   string
+	Purity      Purity
+	Tag         string
+	MatchAnyTag bool
+
+	ShardingKey, SuperShardingKey []interface{}
+}
+
+func (sp sessionParams) equal(other sessionParams) bool {
+	return sp.ConnClass == other.ConnClass && sp.Purity == other.Purity &&
+		sp.Tag == other.Tag && sp.MatchAnyTag == other.MatchAnyTag &&
+		shardingKeyEqual(sp.ShardingKey, other.ShardingKey) &&
+		shardingKeyEqual(sp.SuperShardingKey, other.SuperShardingKey)
+}
+
+func shardingKeyEqual(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, x := range a {
+		switch x := x.(type) {
+		case []byte:
+			if y, ok := b[i].([]byte); !ok || !bytes.Equal(x, y) {
+				return false
+			}
+		case time.Time:
+			if y, ok := b[i].(time.Time); !ok || !x.Equal(y) {
+				return false
+			}
+		default:
+			if !reflect.DeepEqual(x, b[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// setShardingKeys sets the sharding key and super sharding key columns of params.
+// The returned function frees the allocated memory, and must be called after the connection has been acquired.
+func setShardingKeys(params *C.dpiConnCreateParams, key, superKey []interface{}) (func(), error) {
+	var frees []unsafe.Pointer
+	free := func() {
+		for _, p := range frees {
+			C.free(p)
+		}
+	}
+	for _, task := range []struct {
+		Key     []interface{}
+		Columns **C.dpiShardingKeyColumn
+		Num     *C.uint8_t
+	}{
+		{key, &params.shardingKeyColumns, &params.numShardingKeyColumns},
+		{superKey, &params.superShardingKeyColumns, &params.numSuperShardingKeyColumns},
+	} {
+		if len(task.Key) == 0 {
+			continue
+		}
+		if len(task.Key) > math.MaxUint8 {
+			free()
+			return nil, errors.Errorf("too many (%d) sharding key columns", len(task.Key))
+		}
+		n := len(task.Key)
+		ptr := C.malloc(C.size_t(n) * C.sizeof_dpiShardingKeyColumn)
+		frees = append(frees, ptr)
+		cols := (*[math.MaxUint8]C.dpiShardingKeyColumn)(ptr)[:n:n]
+		for i, v := range task.Key {
+			var d C.dpiData
+			col := &cols[i]
+			switch v := v.(type) {
+			case string:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_NATIVE_TYPE_BYTES
+				cs := C.CString(v)
+				frees = append(frees, unsafe.Pointer(cs))
+				C.dpiData_setBytes(&d, cs, C.uint32_t(len(v)))
+			case []byte:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_RAW, C.DPI_NATIVE_TYPE_BYTES
+				cb := C.CBytes(v)
+				frees = append(frees, cb)
+				C.dpiData_setBytes(&d, (*C.char)(cb), C.uint32_t(len(v)))
+			case Number:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_NUMBER, C.DPI_NATIVE_TYPE_BYTES
+				cs := C.CString(string(v))
+				frees = append(frees, unsafe.Pointer(cs))
+				C.dpiData_setBytes(&d, cs, C.uint32_t(len(v)))
+			case int:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_NUMBER, C.DPI_NATIVE_TYPE_INT64
+				C.dpiData_setInt64(&d, C.int64_t(v))
+			case int32:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_NUMBER, C.DPI_NATIVE_TYPE_INT64
+				C.dpiData_setInt64(&d, C.int64_t(v))
+			case int64:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_NUMBER, C.DPI_NATIVE_TYPE_INT64
+				C.dpiData_setInt64(&d, C.int64_t(v))
+			case uint64:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_NUMBER, C.DPI_NATIVE_TYPE_UINT64
+				C.dpiData_setUint64(&d, C.uint64_t(v))
+			case float64:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_NUMBER, C.DPI_NATIVE_TYPE_DOUBLE
+				C.dpiData_setDouble(&d, C.double(v))
+			case time.Time:
+				col.oracleTypeNum, col.nativeTypeNum = C.DPI_ORACLE_TYPE_DATE, C.DPI_NATIVE_TYPE_TIMESTAMP
+				_, z := v.Zone()
+				C.dpiData_setTimestamp(&d,
+					C.int16_t(v.Year()), C.uint8_t(v.Month()), C.uint8_t(v.Day()),
+					C.uint8_t(v.Hour()), C.uint8_t(v.Minute()), C.uint8_t(v.Second()), C.uint32_t(v.Nanosecond()),
+					C.int8_t(z/3600), C.int8_t((z%3600)/60),
+				)
+			default:
+				free()
+				return nil, errors.Errorf("sharding key column %d: unsupported type %T", i, v)
+			}
+			col.value = d.value
+		}
+		*task.Columns, *task.Num = &cols[0], C.uint8_t(n)
+	}
+	return free, nil
+}
+
+// contextSessionParams returns the session parameters set in the context,
+// defaulting to the ones in sp.
+func contextSessionParams(ctx context.Context, sp sessionParams) sessionParams {
+	if cc, ok := ctx.Value(connClassCtxKey).(string); ok {
+		sp.ConnClass = cc
+	}
+	if p, ok := ctx.Value(purityCtxKey).(Purity); ok {
+		sp.Purity = p
+	}
+	if st, ok := ctx.Value(sessionTagCtxKey).(sessionTag); ok {
+		sp.Tag, sp.MatchAnyTag = st.Tag, st.MatchAny
+	}
+	if key, ok := ctx.Value(shardingKeyCtxKey).([]interface{}); ok {
+		sp.ShardingKey = key
+	}
+	if key, ok := ctx.Value(superShardingKeyCtxKey).([]interface{}); ok {
+		sp.SuperShardingKey = key
+	}
+	return sp
+}
+
+// fixupSession calls the session fixup function iff the tag of the acquired session
+// differs from the requested one.
+//
+// Without a fixup function, or if it fails, the session is not retagged on release.
+func (c *conn) fixupSession(ctx context.Context) error {
+	if c.sessionFixup == nil || c.session.Tag == "" || c.session.Tag == c.sessionTag {
 		return nil
 	}
+	if err := c.sessionFixup(ctx, c, c.session.Tag, c.sessionTag); err != nil {
+		return errors.Errorf("session fixup (%q -> %q): %w", c.sessionTag, c.session.Tag, err)
+	}
+	c.mu.Lock()
+	c.retag = true
+	c.mu.Unlock()
+	return nil
+}
 
+// ensureContextUser re-acquires the session iff the user or the session parameters
+// (connection class, purity, tag, sharding keys) set in the context differ from the current session's.
+//
+// Without a user in the context, a heterogeneous pool's session is re-acquired as the current user.
+func (c *conn) ensureContextUser(ctx context.Context) error {
+	P := c.connParams
 	var up [2]string
-	var ok bool
-	if up, ok = ctx.Value(userpwCtxKey).([2]string); !ok || up[0] == c.currentUser {
-		return nil
+	var switchUser bool
+	if P.HeterogeneousPool {
+		if proxyUser, ok := ctx.Value(proxyUserCtxKey).(string); ok {
+			up[0], switchUser = proxyUser, true
+		} else if up, switchUser = ctx.Value(userpwCtxKey).([2]string); !switchUser {
+			if P.ProxyUser != "" {
+				// switch back to the default end user
+				up[0], switchUser = P.ProxyUser, true
+			} else {
+				// keep the current user, not the pool's default one, if only the session params change
+				up = [2]string{c.currentUser, c.currentPassword}
+			}
+		}
+	} else if proxyUser, ok := ctx.Value(proxyUserCtxKey).(string); ok && proxyUser != c.currentUser {
+		// the end user of a homogeneous pool (and of a standalone connection) is fixed at creation
+		return errors.Errorf("ContextWithProxyUser(%q) needs heterogeneousPool=1 in the connection string", proxyUser)
 	}
 
+	standalone := P.IsSysDBA || P.IsSysOper || P.IsSysASM || P.IsPrelim || P.StandaloneConnection
+	sameSession := contextSessionParams(ctx, c.session).equal(c.session)
+	if sameSession && (!switchUser || up[0] == c.currentUser) {
+		return nil
+	}
+	if standalone {
+		if !sameSession {
+			return errors.New("cannot change the session params (purity, connection class, tag or sharding key) of a standalone connection")
+		}
+		return errors.Errorf("cannot switch user to %q on a standalone connection", up[0])
+	}
+	c.RLock()
+	inTran := c.inTransaction
+	c.RUnlock()
+	if inTran {
+		// releasing the session would lose the uncommitted work
+		return errors.New("cannot change the session (user, purity, connection class, tag or sharding key) in a transaction")
+	}
 	if c.dpiConn != nil {
 		if err := c.Close(); err != nil {
 			return driver.ErrBadConn
@@ -716,13 +1037,16 @@ func (c *conn) ensureContextUser(ctx context.Context) error {
 	}
 
 	c.Lock()
-	defer c.Unlock()
-
-	if err := c.acquireConn(up[0], up[1]); err != nil {
+	err := c.acquireConn(ctx, up[0], up[1])
+	if err == nil {
+		err = c.init()
+	}
+	c.Unlock()
+	if err != nil {
 		return err
 	}
 
-	return c.init()
+	return c.fixupSession(ctx)
 }
 
 // StartupMode for the database.
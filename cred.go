@@ -0,0 +1,90 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+// CredentialProvider provides the user name and password for a new pool
+// or standalone connection, instead of the ones in the connection string.
+//
+// It is called each time a new pool or standalone connection is created,
+// so it can return rotated passwords.
+// An empty username means the one from the connection string.
+type CredentialProvider interface {
+	Credentials(context.Context) (username, password string, err error)
+}
+
+// CredentialProviderFunc is a function implementing CredentialProvider.
+type CredentialProviderFunc func(context.Context) (username, password string, err error)
+
+// Credentials calls f(ctx).
+func (f CredentialProviderFunc) Credentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+// FileCredentials returns a CredentialProvider which reads the password
+// (and the user name, if userFile is not empty) from the given files on each call.
+//
+// Trailing newlines are trimmed.
+func FileCredentials(userFile, passwordFile string) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context) (string, string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+		var username string
+		if userFile != "" {
+			b, err := ioutil.ReadFile(userFile)
+			if err != nil {
+				return "", "", errors.Errorf("read user from %q: %w", userFile, err)
+			}
+			username = strings.TrimRight(string(b), "\r\n")
+		}
+		b, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return "", "", errors.Errorf("read password from %q: %w", passwordFile, err)
+		}
+		return username, strings.TrimRight(string(b), "\r\n"), nil
+	})
+}
+
+// EnvCredentials returns a CredentialProvider which reads the password
+// (and the user name, if userVar is not empty) from the given environment variables on each call.
+func EnvCredentials(userVar, passwordVar string) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context) (string, string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+		var username string
+		if userVar != "" {
+			var ok bool
+			if username, ok = os.LookupEnv(userVar); !ok {
+				return "", "", errors.Errorf("%s is not set", userVar)
+			}
+		}
+		password, ok := os.LookupEnv(passwordVar)
+		if !ok {
+			return "", "", errors.Errorf("%s is not set", passwordVar)
+		}
+		return username, password, nil
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goracle-cred-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	userFile, passwordFile := filepath.Join(dir, "user"), filepath.Join(dir, "password")
+	if err = ioutil.WriteFile(userFile, []byte("scott\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, passw := range []string{"tiger", "rotated"} {
+		if err = ioutil.WriteFile(passwordFile, []byte(passw+"\r\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		u, p, err := FileCredentials(userFile, passwordFile).Credentials(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != "scott" || p != passw {
+			t.Errorf("got %q/%q, wanted %q/%q", u, p, "scott", passw)
+		}
+		if u, _, err = FileCredentials("", passwordFile).Credentials(ctx); err != nil {
+			t.Fatal(err)
+		} else if u != "" {
+			t.Errorf("got user %q, wanted empty", u)
+		}
+	}
+	if _, _, err = FileCredentials("", filepath.Join(dir, "nonexistent")).Credentials(ctx); err == nil {
+		t.Error("wanted error for nonexistent file")
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	const userVar, passwordVar = "GORACLE_TEST_CRED_USER", "GORACLE_TEST_CRED_PASSWORD"
+	os.Setenv(userVar, "scott")
+	os.Setenv(passwordVar, "tiger")
+	defer os.Unsetenv(userVar)
+	defer os.Unsetenv(passwordVar)
+
+	ctx := context.Background()
+	u, p, err := EnvCredentials(userVar, passwordVar).Credentials(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "scott" || p != "tiger" {
+		t.Errorf("got %q/%q, wanted scott/tiger", u, p)
+	}
+
+	P := ConnectionParams{Username: "dsnuser", Password: "dsnpass"}
+	if u, p, err = getCredentials(ctx, P, EnvCredentials("", passwordVar)); err != nil {
+		t.Fatal(err)
+	} else if u != "dsnuser" || p != "tiger" {
+		t.Errorf("got %q/%q, wanted dsnuser/tiger", u, p)
+	}
+
+	os.Unsetenv(passwordVar)
+	if _, _, err = EnvCredentials(userVar, passwordVar).Credentials(ctx); err == nil {
+		t.Error("wanted error for unset variable")
+	}
+}
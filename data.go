@@ -147,7 +147,16 @@ func (d *Data) GetIntervalDS() time.Duration {
 	if d.IsNull() {
 		return 0
 	}
-	ds := C.dpiData_getIntervalDS(d.dpiData)
+	return getIntervalDS(d.dpiData)
+}
+
+// SetIntervalDS sets the duration as interval date-seconds to data.
+func (d *Data) SetIntervalDS(dur time.Duration) {
+	setIntervalDS(d.dpiData, dur)
+}
+
+func getIntervalDS(data *C.dpiData) time.Duration {
+	ds := C.dpiData_getIntervalDS(data)
 	return time.Duration(ds.days)*24*time.Hour +
 		time.Duration(ds.hours)*time.Hour +
 		time.Duration(ds.minutes)*time.Minute +
@@ -155,12 +164,20 @@ func (d *Data) GetIntervalDS() time.Duration {
 		time.Duration(ds.fseconds)
 }
 
-// SetIntervalDS sets the duration as interval date-seconds to data.
-func (d *Data) SetIntervalDS(dur time.Duration) {
-	C.dpiData_setIntervalDS(d.dpiData,
-		C.int32_t(int64(dur.Hours())/24),
-		C.int32_t(int64(dur.Hours())%24), C.int32_t(dur.Minutes()), C.int32_t(dur.Seconds()),
-		C.int32_t(dur.Nanoseconds()),
+// setIntervalDS sets the duration, split into days, hours, minutes, seconds and nanoseconds
+// (all with the same sign).
+func setIntervalDS(data *C.dpiData, dur time.Duration) {
+	days := dur / (24 * time.Hour)
+	dur -= days * 24 * time.Hour
+	hours := dur / time.Hour
+	dur -= hours * time.Hour
+	minutes := dur / time.Minute
+	dur -= minutes * time.Minute
+	seconds := dur / time.Second
+	dur -= seconds * time.Second
+	C.dpiData_setIntervalDS(data,
+		C.int32_t(days), C.int32_t(hours), C.int32_t(minutes), C.int32_t(seconds),
+		C.int32_t(dur),
 	)
 }
 
@@ -402,14 +419,17 @@ func newVarInfo(baseType interface{}, sliceLen, bufSize int) (varInfo, error) {
 	switch v := baseType.(type) {
 	case Lob, []Lob:
 		vi.NatTyp = C.DPI_NATIVE_TYPE_LOB
-		var isClob bool
+		var isClob, isNClob bool
 		switch v := v.(type) {
 		case Lob:
-			isClob = v.IsClob
+			isClob, isNClob = v.IsClob, v.IsNClob
 		case []Lob:
 			isClob = len(v) > 0 && v[0].IsClob
+			isNClob = len(v) > 0 && v[0].IsNClob
 		}
-		if isClob {
+		if isNClob {
+			vi.Typ = C.DPI_ORACLE_TYPE_NCLOB
+		} else if isClob {
 			vi.Typ = C.DPI_ORACLE_TYPE_CLOB
 		} else {
 			vi.Typ = C.DPI_ORACLE_TYPE_BLOB
@@ -445,6 +465,9 @@ func newVarInfo(baseType interface{}, sliceLen, bufSize int) (varInfo, error) {
 	case string, []string, nil:
 		vi.Typ, vi.NatTyp = C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_NATIVE_TYPE_BYTES
 		bufSize = 32767
+	case NString, []NString:
+		vi.Typ, vi.NatTyp = C.DPI_ORACLE_TYPE_NVARCHAR, C.DPI_NATIVE_TYPE_BYTES
+		bufSize = 32767
 	case time.Time, []time.Time:
 		vi.Typ, vi.NatTyp = C.DPI_ORACLE_TYPE_DATE, C.DPI_NATIVE_TYPE_TIMESTAMP
 	case userType, []userType:
@@ -34,7 +34,11 @@
 //     prelim=0& \
 //     poolWaitTimeout=300& \
 //     poolSessionMaxLifetime=3600& \
-//     poolSessionTimeout=3000
+//     poolSessionTimeout=3000& \
+//     proxyUser=& \
+//     purity=default& \
+//     nullAsNil=0& \
+//     nativeNumbers=0
 //
 // These are the defaults. Many advocate that a static session pool (min=max, incr=0)
 // is better, with 1-10 sessions per CPU thread.
@@ -44,6 +48,11 @@
 // If you specify connectionClass, that'll reuse the same session pool
 // without the connectionClass, but will specify it on each session acquire.
 // Thus you can cluster the session pool with classes, or use POOLED for DRCP.
+// With DRCP, purity=new or purity=self can be set, too.
+//
+// With nullAsNil=1, all NULL columns are returned as nil (see the NullAsNil option).
+// With nativeNumbers=1, the NUMBER columns with known precision are fetched
+// as int64 or float64 (see the NativeNumbers option).
 //
 // For what can be used as "sid", see https://docs.oracle.com/en/database/oracle/oracle-database/19/netag/configuring-naming-methods.html#GUID-E5358DEA-D619-4B7B-A799-3D2F802500F1
 package goracle
@@ -343,6 +352,7 @@ type connPool struct {
 	serverVersion VersionInfo
 	timeZone      *time.Location
 	tzOffSecs     int
+	credHash      uint64
 }
 
 func (d *drv) init() error {
@@ -385,12 +395,30 @@ func (d *drv) ClientVersion() (VersionInfo, error) {
 }
 
 func (d *drv) openConn(P ConnectionParams) (*conn, error) {
+	return d.openConnContext(context.Background(), P, connOptions{})
+}
+
+// connOptions are the connector-level options of the connections.
+type connOptions struct {
+	// credentials is asked for the credentials when a new pool or
+	// standalone connection has to be created.
+	credentials CredentialProvider
+	// sessionFixup is called when the tag of the acquired session differs from the requested.
+	sessionFixup SessionFixupFunc
+}
+
+// openConnContext opens a connection with the given params and options.
+func (d *drv) openConnContext(ctx context.Context, P ConnectionParams, opts connOptions) (*conn, error) {
 	if err := d.init(); err != nil {
 		return nil, err
 	}
 
-	c := conn{drv: d, connParams: P, timeZone: time.Local}
-	connString := P.String()
+	c := conn{drv: d, connParams: P, timeZone: time.Local,
+		session:      sessionParams{ConnClass: P.ConnClass, Purity: P.Purity},
+		sessionFixup: opts.sessionFixup,
+	}
+	cp := opts.credentials
+	connString := P.poolKey()
 
 	defer func() {
 		d.mu.Lock()
@@ -420,19 +448,6 @@ func (d *drv) openConn(P ConnectionParams) (*conn, error) {
 		P.ConnClass = ""
 	}
 
-	extAuth := C.int(b2i(P.Username == "" && P.Password == ""))
-	var connCreateParams C.dpiConnCreateParams
-	if C.dpiContext_initConnCreateParams(d.dpiContext, &connCreateParams) == C.DPI_FAILURE {
-		return nil, errors.Errorf("initConnCreateParams: %w", d.getError())
-	}
-	connCreateParams.authMode = authMode
-	connCreateParams.externalAuth = extAuth
-	if P.ConnClass != "" {
-		cConnClass := C.CString(P.ConnClass)
-		defer C.free(unsafe.Pointer(cConnClass))
-		connCreateParams.connectionClass = cConnClass
-		connCreateParams.connectionClassLength = C.uint32_t(len(P.ConnClass))
-	}
 	if !(P.IsSysDBA || P.IsSysOper || P.IsSysASM || P.IsPrelim || P.StandaloneConnection) {
 		d.mu.Lock()
 		dp := d.pools[connString]
@@ -443,7 +458,20 @@ func (d *drv) openConn(P ConnectionParams) (*conn, error) {
 			c.Client, c.Server = d.clientVersion, dp.serverVersion
 			c.timeZone, c.tzOffSecs = dp.timeZone, dp.tzOffSecs
 			c.mu.Unlock()
-			if err := c.acquireConn("", ""); err != nil {
+			// ODPI-C does not allow a user on acquire from a homogeneous pool (DPI-1012)
+			var proxyUser string
+			if P.HeterogeneousPool {
+				proxyUser = P.ProxyUser
+			}
+			if err := c.acquireConn(ctx, proxyUser, ""); err != nil {
+				if cp != nil && isInvalidCredentials(err) {
+					// The password may have been rotated: recreate the pool iff the credentials has changed.
+					username, password, credErr := getCredentials(ctx, P, cp)
+					if credErr == nil && credentialsHash(username, password) != dp.credHash {
+						d.dropPool(connString, dp)
+						return d.openConnContext(ctx, P, opts)
+					}
+				}
 				return nil, err
 			}
 			err := c.init()
@@ -452,14 +480,43 @@ func (d *drv) openConn(P ConnectionParams) (*conn, error) {
 				dp.serverVersion = c.Server
 				dp.timeZone, dp.tzOffSecs = c.timeZone, c.tzOffSecs
 				c.mu.Unlock()
+				err = c.fixupSession(ctx)
 			}
 			return &c, err
 		}
 	}
 
+	username, password, err := getCredentials(ctx, P, cp)
+	if err != nil {
+		return nil, err
+	}
+	extAuth := C.int(b2i(username == "" && password == ""))
+	var connCreateParams C.dpiConnCreateParams
+	if C.dpiContext_initConnCreateParams(d.dpiContext, &connCreateParams) == C.DPI_FAILURE {
+		return nil, errors.Errorf("initConnCreateParams: %w", d.getError())
+	}
+	connCreateParams.authMode = authMode
+	connCreateParams.externalAuth = extAuth
+	sp := contextSessionParams(ctx, c.session)
+	if P.IsPrelim {
+		sp.ConnClass = ""
+	}
+	connCreateParams.purity = C.dpiPurity(sp.Purity)
+	freeShardingKeys, err := setShardingKeys(&connCreateParams, sp.ShardingKey, sp.SuperShardingKey)
+	if err != nil {
+		return nil, err
+	}
+	defer freeShardingKeys()
+	if sp.ConnClass != "" {
+		cConnClass := C.CString(sp.ConnClass)
+		defer C.free(unsafe.Pointer(cConnClass))
+		connCreateParams.connectionClass = cConnClass
+		connCreateParams.connectionClassLength = C.uint32_t(len(sp.ConnClass))
+	}
+
 	var cUserName, cPassword *C.char
-	if !(P.Username == "" && P.Password == "") {
-		cUserName, cPassword = C.CString(P.Username), C.CString(P.Password)
+	if !(username == "" && password == "") {
+		cUserName, cPassword = C.CString(username), C.CString(password)
 	}
 	var cSid *C.char
 	if P.SID != "" {
@@ -492,25 +549,41 @@ func (d *drv) openConn(P ConnectionParams) (*conn, error) {
 	commonCreateParams.driverName = cDriverName
 	commonCreateParams.driverNameLength = C.uint32_t(len(DriverName))
 
-	if P.IsSysDBA || P.IsSysOper || P.IsSysASM || P.IsPrelim || P.StandaloneConnection {
+	standalone := P.IsSysDBA || P.IsSysOper || P.IsSysASM || P.IsPrelim || P.StandaloneConnection
+	// A standalone connection, and a homogeneous pool, can be proxied with the "appuser[enduser]" syntax;
+	// a heterogeneous pool acquires its sessions with the end user instead.
+	connUser := username
+	if P.ProxyUser != "" && cUserName != nil && (standalone || !P.HeterogeneousPool) {
+		connUser = username + "[" + P.ProxyUser + "]"
+		C.free(unsafe.Pointer(cUserName))
+		cUserName = C.CString(connUser)
+	}
+
+	if standalone {
 		dc := C.malloc(C.sizeof_void)
 		if Log != nil {
 			Log("C", "dpiConn_create", "params", P.String(), "common", commonCreateParams, "conn", connCreateParams)
 		}
 		if C.dpiConn_create(
 			d.dpiContext,
-			cUserName, C.uint32_t(len(P.Username)),
-			cPassword, C.uint32_t(len(P.Password)),
+			cUserName, C.uint32_t(len(connUser)),
+			cPassword, C.uint32_t(len(password)),
 			cSid, C.uint32_t(len(P.SID)),
 			&commonCreateParams,
 			&connCreateParams,
 			(**C.dpiConn)(unsafe.Pointer(&dc)),
 		) == C.DPI_FAILURE {
 			C.free(unsafe.Pointer(dc))
-			return nil, errors.Errorf("username=%q sid=%q params=%+v: %w", P.Username, P.SID, connCreateParams, d.getError())
+			return nil, errors.Errorf("username=%q sid=%q params=%+v: %w", connUser, P.SID, connCreateParams, d.getError())
 		}
 		c.dpiConn = (*C.dpiConn)(dc)
-		c.currentUser = P.Username
+		c.currentUser = username
+		if P.ProxyUser != "" {
+			c.currentUser = P.ProxyUser
+		}
+		// all the session params of the context are applied (the tag is only recorded),
+		// so ensureContextUser finds the same session for the same context.
+		c.session = sp
 		c.newSession = true
 		err := c.init()
 		return &c, err
@@ -551,12 +624,12 @@ func (d *drv) openConn(P ConnectionParams) (*conn, error) {
 
 	var dp *C.dpiPool
 	if Log != nil {
-		Log("C", "dpiPool_create", "username", P.Username, "conn", connString, "sid", P.SID, "common", commonCreateParams, "pool", fmt.Sprintf("%#v", poolCreateParams))
+		Log("C", "dpiPool_create", "username", connUser, "conn", connString, "sid", P.SID, "common", commonCreateParams, "pool", fmt.Sprintf("%#v", poolCreateParams))
 	}
 	if C.dpiPool_create(
 		d.dpiContext,
-		cUserName, C.uint32_t(len(P.Username)),
-		cPassword, C.uint32_t(len(P.Password)),
+		cUserName, C.uint32_t(len(connUser)),
+		cPassword, C.uint32_t(len(password)),
 		cSid, C.uint32_t(len(P.SID)),
 		&commonCreateParams,
 		&poolCreateParams,
@@ -566,17 +639,88 @@ func (d *drv) openConn(P ConnectionParams) (*conn, error) {
 	}
 	C.dpiPool_setStmtCacheSize(dp, 40)
 	d.mu.Lock()
-	d.pools[connString] = &connPool{dpiPool: dp}
+	d.pools[connString] = &connPool{dpiPool: dp, credHash: credentialsHash(username, password)}
 	d.mu.Unlock()
 
-	return d.openConn(P)
+	return d.openConnContext(ctx, P, opts)
+}
+
+// getCredentials returns the username and password from cp, or from P if cp is nil.
+func getCredentials(ctx context.Context, P ConnectionParams, cp CredentialProvider) (username, password string, err error) {
+	if cp == nil {
+		return P.Username, P.Password, nil
+	}
+	if username, password, err = cp.Credentials(ctx); err != nil {
+		return "", "", errors.Errorf("get credentials: %w", err)
+	}
+	if username == "" {
+		username = P.Username
+	}
+	return username, password, nil
+}
+
+func credentialsHash(username, password string) uint64 {
+	hsh := fnv.New64()
+	io.WriteString(hsh, username)
+	hsh.Write([]byte{0})
+	io.WriteString(hsh, password)
+	return hsh.Sum64()
+}
+
+// isInvalidCredentials reports whether the error is ORA-01017: invalid username/password; logon denied
+func isInvalidCredentials(err error) bool {
+	var cd interface{ Code() int }
+	return errors.As(err, &cd) && cd.Code() == 1017
+}
+
+// dropPool removes the pool from the cache, closes it if it has no busy sessions,
+// and releases the driver's reference to it.
+//
+// Each connection acquired from the pool (and each acquireConn in progress) holds a reference to it,
+// so if some sessions are busy (and the close fails), the pool is destroyed when the last of them is released.
+func (d *drv) dropPool(connString string, dp *connPool) {
+	d.mu.Lock()
+	if d.pools[connString] == dp {
+		delete(d.pools, connString)
+	}
+	d.mu.Unlock()
+	if C.dpiPool_close(dp.dpiPool, C.DPI_MODE_POOL_CLOSE_DEFAULT) == C.DPI_FAILURE {
+		if Log != nil {
+			Log("msg", "dropPool", "conn", connString, "close", d.getError())
+		}
+	} else if Log != nil {
+		Log("msg", "dropPool", "conn", connString)
+	}
+	C.dpiPool_release(dp.dpiPool)
 }
 
-func (c *conn) acquireConn(user, pass string) error {
+// acquireConn acquires a session from the pool, with the session parameters
+// (connection class, purity, tag, sharding keys) set in the context, or in the connection params.
+func (c *conn) acquireConn(ctx context.Context, user, pass string) error {
 	var connCreateParams C.dpiConnCreateParams
 	if C.dpiContext_initConnCreateParams(c.dpiContext, &connCreateParams) == C.DPI_FAILURE {
 		return errors.Errorf("initConnCreateParams: %w", "", c.getError())
 	}
+	sp := contextSessionParams(ctx, c.session)
+	if sp.ConnClass != "" {
+		cConnClass := C.CString(sp.ConnClass)
+		defer C.free(unsafe.Pointer(cConnClass))
+		connCreateParams.connectionClass = cConnClass
+		connCreateParams.connectionClassLength = C.uint32_t(len(sp.ConnClass))
+	}
+	connCreateParams.purity = C.dpiPurity(sp.Purity)
+	if sp.Tag != "" {
+		cTag := C.CString(sp.Tag)
+		defer C.free(unsafe.Pointer(cTag))
+		connCreateParams.tag = cTag
+		connCreateParams.tagLength = C.uint32_t(len(sp.Tag))
+		connCreateParams.matchAnyTag = C.int(b2i(sp.MatchAnyTag))
+	}
+	freeShardingKeys, err := setShardingKeys(&connCreateParams, sp.ShardingKey, sp.SuperShardingKey)
+	if err != nil {
+		return err
+	}
+	defer freeShardingKeys()
 
 	dc := C.malloc(C.sizeof_void)
 	if Log != nil {
@@ -598,9 +742,17 @@ func (c *conn) acquireConn(user, pass string) error {
 		cPassword = C.CString(pass)
 	}
 
+	// the reference keeps the pool alive while acquiring, even if dropPool runs concurrently
 	c.drv.mu.Lock()
-	pool := c.pools[c.connParams.String()]
+	pool := c.pools[c.connParams.poolKey()]
+	if pool != nil && C.dpiPool_addRef(pool.dpiPool) == C.DPI_FAILURE {
+		pool = nil
+	}
 	c.drv.mu.Unlock()
+	if pool == nil {
+		return driver.ErrBadConn
+	}
+	defer C.dpiPool_release(pool.dpiPool)
 	if C.dpiPool_acquireConnection(
 		pool.dpiPool,
 		cUserName, C.uint32_t(len(user)), cPassword, C.uint32_t(len(pass)),
@@ -613,12 +765,26 @@ func (c *conn) acquireConn(user, pass string) error {
 
 	c.mu.Lock()
 	c.dpiConn = (*C.dpiConn)(dc)
-	c.currentUser = user
+	c.currentUser, c.currentPassword = user, pass
+	if user == "" {
+		// the end user of a homogeneous pool is fixed (see ConnectionParams.ProxyUser)
+		c.currentUser = c.connParams.ProxyUser
+	}
+	c.session, c.sessionTag = sp, ""
+	if connCreateParams.outTagFound == 1 && connCreateParams.outTagLength != 0 {
+		c.sessionTag = C.GoStringN(connCreateParams.outTag, C.int(connCreateParams.outTagLength))
+	}
+	c.retag = sp.Tag != "" && c.sessionTag == sp.Tag
 	c.newSession = connCreateParams.outNewSession == 1
+	c.tzRegions = false
 	c.Client, c.Server = c.drv.clientVersion, pool.serverVersion
 	c.timeZone, c.tzOffSecs = pool.timeZone, pool.tzOffSecs
 	c.mu.Unlock()
-	err := c.init()
+	if Log != nil {
+		Log("msg", "acquireConn", "user", user, "connClass", sp.ConnClass, "purity", sp.Purity,
+			"tag", sp.Tag, "sessionTag", c.sessionTag, "newSession", c.newSession)
+	}
+	err = c.init()
 	if err == nil {
 		c.mu.Lock()
 		pool.serverVersion = c.Server
@@ -632,14 +798,27 @@ func (c *conn) acquireConn(user, pass string) error {
 // ConnectionParams holds the params for a connection (pool).
 // You can use ConnectionParams{...}.StringWithPassword()
 // as a connection string in sql.Open.
+//
+// ProxyUser is the end user for proxy authentication (as in "appuser[enduser]").
+// The pool is created (homogeneous) as "Username[ProxyUser]", so the end user is fixed;
+// with HeterogeneousPool, it is the default end user, which can be changed with ContextWithProxyUser.
+//
+// Purity is the DRCP session purity requested on session acquire,
+// which can be changed with ContextWithPurity.
 type ConnectionParams struct {
 	Username, Password, SID, ConnClass       string
+	ProxyUser                                string
+	Purity                                   Purity
 	MinSessions, MaxSessions, PoolIncrement  int
 	WaitTimeout, MaxLifeTime, SessionTimeout int
 	IsSysDBA, IsSysOper, IsSysASM, IsPrelim  bool
 	HeterogeneousPool                        bool
 	StandaloneConnection                     bool
 	EnableEvents                             bool
+	// NullAsNil sets the NullAsNil option for all statements.
+	NullAsNil bool
+	// NativeNumbers sets the NativeNumbers option for all statements.
+	NativeNumbers bool
 }
 
 // String returns the string representation of ConnectionParams.
@@ -648,6 +827,13 @@ func (P ConnectionParams) String() string {
 	return P.string(true, false)
 }
 
+// poolKey returns the key of the session pool of the ConnectionParams:
+// String without the client-side flags, which do not change the sessions.
+func (P ConnectionParams) poolKey() string {
+	P.NullAsNil, P.NativeNumbers = false, false
+	return P.String()
+}
+
 // StringNoClass returns the string representation of ConnectionParams, without class info.
 // The password is replaced with a "SECRET" string!
 func (P ConnectionParams) StringNoClass() string {
@@ -669,6 +855,19 @@ func (P ConnectionParams) string(class, withPassword bool) string {
 	if class {
 		cc = fmt.Sprintf("connectionClass=%s&", url.QueryEscape(P.ConnClass))
 	}
+	var extra string
+	if P.ProxyUser != "" {
+		extra = "&proxyUser=" + url.QueryEscape(P.ProxyUser)
+	}
+	if P.Purity != PurityDefault {
+		extra += "&purity=" + P.Purity.String()
+	}
+	if P.NullAsNil {
+		extra += "&nullAsNil=1"
+	}
+	if P.NativeNumbers {
+		extra += "&nativeNumbers=1"
+	}
 	// params should be sorted lexicographically
 	password := P.Password
 	if !withPassword {
@@ -692,10 +891,45 @@ func (P ConnectionParams) string(class, withPassword bool) string {
 				b2i(P.StandaloneConnection), b2i(P.EnableEvents),
 				b2i(P.HeterogeneousPool), b2i(P.IsPrelim),
 				P.WaitTimeout, P.MaxLifeTime, P.SessionTimeout,
-			),
+			) + extra,
 	}).String()
 }
 
+// Purity is the DRCP session purity.
+type Purity C.dpiPurity
+
+const (
+	// PurityDefault lets the database decide: NEW for standalone connections, SELF for pooled sessions.
+	PurityDefault = Purity(C.DPI_PURITY_DEFAULT)
+	// PurityNew requests a session without any state from earlier usage.
+	PurityNew = Purity(C.DPI_PURITY_NEW)
+	// PuritySelf allows reusing a session with state from earlier usage.
+	PuritySelf = Purity(C.DPI_PURITY_SELF)
+)
+
+func (p Purity) String() string {
+	switch p {
+	case PurityNew:
+		return "new"
+	case PuritySelf:
+		return "self"
+	default:
+		return "default"
+	}
+}
+
+func parsePurity(s string) (Purity, error) {
+	switch strings.ToLower(s) {
+	case "", "default":
+		return PurityDefault, nil
+	case "new":
+		return PurityNew, nil
+	case "self":
+		return PuritySelf, nil
+	}
+	return PurityDefault, errors.Errorf("purity=%s: unknown purity (should be default, new or self)", s)
+}
+
 // ParseConnString parses the given connection string into a struct.
 func ParseConnString(connString string) (ConnectionParams, error) {
 	P := ConnectionParams{
@@ -755,6 +989,10 @@ func ParseConnString(connString string) (ConnectionParams, error) {
 	if vv, ok := q["connectionClass"]; ok {
 		P.ConnClass = vv[0]
 	}
+	P.ProxyUser = q.Get("proxyUser")
+	if P.Purity, err = parsePurity(q.Get("purity")); err != nil {
+		return P, err
+	}
 	for _, task := range []struct {
 		Dest *bool
 		Key  string
@@ -767,6 +1005,8 @@ func ParseConnString(connString string) (ConnectionParams, error) {
 		{&P.StandaloneConnection, "standaloneConnection"},
 		{&P.EnableEvents, "enableEvents"},
 		{&P.HeterogeneousPool, "heterogeneousPool"},
+		{&P.NullAsNil, "nullAsNil"},
+		{&P.NativeNumbers, "nativeNumbers"},
 	} {
 		*task.Dest = q.Get(task.Key) == "1"
 	}
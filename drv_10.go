@@ -32,6 +32,33 @@ type connector struct {
 	ConnectionParams
 	*drv
 	onInit func(driver.Conn) error
+	opts   connOptions
+}
+
+// ConnectorOption is an option for NewConnector.
+type ConnectorOption func(*connector)
+
+// WithCredentials returns a ConnectorOption to ask cp for the username and password
+// each time a new pool or standalone connection is created.
+//
+// The pool is keyed by the connection string (without the provided password),
+// so it is recreated transparently (ORA-01017) when the password is rotated.
+func WithCredentials(cp CredentialProvider) ConnectorOption {
+	return func(c *connector) { c.opts.credentials = cp }
+}
+
+// WithSessionFixup returns a ConnectorOption to call fixup when the session
+// acquired from the pool has a different tag than the requested one (see ContextWithSessionTag).
+//
+// The fixup can adjust the session state (NLS settings, time zone...) to
+// match the requested tag, and the session is retagged with the requested tag on release.
+func WithSessionFixup(fixup SessionFixupFunc) ConnectorOption {
+	return func(c *connector) { c.opts.sessionFixup = fixup }
+}
+
+// WithNativeNumbers returns a ConnectorOption to set the NativeNumbers option for all statements.
+func WithNativeNumbers() ConnectorOption {
+	return func(c *connector) { c.ConnectionParams.NativeNumbers = true }
 }
 
 // OpenConnector must parse the name in the same format that Driver.Open
@@ -56,8 +83,8 @@ func (d *drv) OpenConnector(name string) (driver.Connector, error) {
 //
 // The returned connection is only used by one goroutine at a
 // time.
-func (c connector) Connect(context.Context) (driver.Conn, error) {
-	conn, err := c.drv.openConn(c.ConnectionParams)
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.drv.openConnContext(ctx, c.ConnectionParams, c.opts)
 	if err != nil || c.onInit == nil || !conn.newSession {
 		return conn, err
 	}
@@ -75,13 +102,18 @@ func (c connector) Driver() driver.Driver { return c.drv }
 
 // NewConnector returns a driver.Connector to be used with sql.OpenDB,
 // which calls the given onInit if the connection is new.
-func NewConnector(name string, onInit func(driver.Conn) error) (driver.Connector, error) {
+func NewConnector(name string, onInit func(driver.Conn) error, options ...ConnectorOption) (driver.Connector, error) {
 	cxr, err := defaultDrv.OpenConnector(name)
 	if err != nil {
 		return nil, err
 	}
 	cx := cxr.(connector)
 	cx.onInit = onInit
+	for _, o := range options {
+		if o != nil {
+			o(&cx)
+		}
+	}
 	return cx, err
 }
 
@@ -0,0 +1,1403 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+// GeometryType is the type of a Geometry, with the WKB type codes.
+type GeometryType uint8
+
+// The geometry types.
+const (
+	GeometryPoint              = GeometryType(1)
+	GeometryLineString         = GeometryType(2)
+	GeometryPolygon            = GeometryType(3)
+	GeometryMultiPoint         = GeometryType(4)
+	GeometryMultiLineString    = GeometryType(5)
+	GeometryMultiPolygon       = GeometryType(6)
+	GeometryGeometryCollection = GeometryType(7)
+)
+
+var geometryTypeNames = [...]string{
+	GeometryPoint:              "Point",
+	GeometryLineString:         "LineString",
+	GeometryPolygon:            "Polygon",
+	GeometryMultiPoint:         "MultiPoint",
+	GeometryMultiLineString:    "MultiLineString",
+	GeometryMultiPolygon:       "MultiPolygon",
+	GeometryGeometryCollection: "GeometryCollection",
+}
+
+// String returns the GeoJSON name of the type.
+func (t GeometryType) String() string {
+	if t == 0 || int(t) >= len(geometryTypeNames) {
+		return "Unknown"
+	}
+	return geometryTypeNames[t]
+}
+
+// memberType returns the type of the members of the Multi* types.
+func (t GeometryType) memberType() GeometryType {
+	switch t {
+	case GeometryMultiPoint:
+		return GeometryPoint
+	case GeometryMultiLineString:
+		return GeometryLineString
+	case GeometryMultiPolygon:
+		return GeometryPolygon
+	}
+	return 0
+}
+
+// ErrGeometry is returned for invalid or unsupported geometries.
+var ErrGeometry = errors.New("invalid geometry")
+
+// Geometry is a simple features geometry (point, line string, polygon, their multi versions
+// and geometry collection), with an SRID.
+//
+// It can be scanned from MDSYS.SDO_GEOMETRY columns, from WKT strings and WKB bytes,
+// and bound as MDSYS.SDO_GEOMETRY (see NewObject for other types).
+// It can be converted to and from WKT, WKB and GeoJSON (as json.Marshaler and json.Unmarshaler).
+//
+// The zero Geometry is NULL.
+type Geometry struct {
+	Type GeometryType
+	// SRID is the coordinate system (such as 4326 for WGS 84); 0 means none (NULL).
+	SRID int
+	// Dims is the number of coordinates of the points: 2 (x, y) or 3 (x, y, z); 0 means 2.
+	Dims int
+	// Coords are the points of a LineString, or the only point of a Point (none for an empty Point).
+	Coords [][]float64
+	// Rings are the rings of a Polygon: the exterior ring and the holes.
+	Rings [][][]float64
+	// Members are the geometries of the Multi* types and the GeometryCollection.
+	Members []Geometry
+}
+
+// IsNull reports whether the Geometry is NULL (the zero value).
+func (g Geometry) IsNull() bool { return g.Type == 0 }
+
+// dims returns the number of coordinates.
+func (g Geometry) dims() int {
+	if g.Dims == 0 {
+		return 2
+	}
+	return g.Dims
+}
+
+// check the geometry's structure.
+func (g Geometry) check() error {
+	if g.Type == 0 || int(g.Type) >= len(geometryTypeNames) {
+		return errors.Errorf("type %d: %w", g.Type, ErrGeometry)
+	}
+	dims := g.dims()
+	if dims != 2 && dims != 3 {
+		return errors.Errorf("%d dimensions: %w", dims, ErrGeometry)
+	}
+	checkCoords := func(coords [][]float64) error {
+		for _, c := range coords {
+			if len(c) != dims {
+				return errors.Errorf("%v: point %v has not %d coordinates: %w", g.Type, c, dims, ErrGeometry)
+			}
+		}
+		return nil
+	}
+	switch g.Type {
+	case GeometryPoint:
+		if len(g.Coords) > 1 {
+			return errors.Errorf("point with %d coordinates: %w", len(g.Coords), ErrGeometry)
+		}
+		return checkCoords(g.Coords)
+	case GeometryLineString:
+		if len(g.Coords) == 1 {
+			return errors.Errorf("line string of one point: %w", ErrGeometry)
+		}
+		return checkCoords(g.Coords)
+	case GeometryPolygon:
+		for _, r := range g.Rings {
+			if len(r) < 4 {
+				return errors.Errorf("ring of %d points: %w", len(r), ErrGeometry)
+			}
+			if err := checkCoords(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	mt := g.Type.memberType()
+	for i, m := range g.Members {
+		if mt != 0 && m.Type != mt {
+			return errors.Errorf("%v with %v member: %w", g.Type, m.Type, ErrGeometry)
+		}
+		if m.dims() != dims {
+			return errors.Errorf("%d. member has %d dimensions, wanted %d: %w", i, m.dims(), dims, ErrGeometry)
+		}
+		if err := m.check(); err != nil {
+			return errors.Errorf("%d. member: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// sdoGeometry is the content of an SDO_GEOMETRY object.
+type sdoGeometry struct {
+	GType, SRID int
+	// Point is the SDO_POINT (x, y, z - which may be NaN for NULL), or nil.
+	Point     []float64
+	ElemInfo  []int
+	Ordinates []float64
+}
+
+// The SDO_GTYPE geometry types (the last two digits).
+const (
+	sdoPoint           = 1
+	sdoLine            = 2
+	sdoPolygon         = 3
+	sdoCollection      = 4
+	sdoMultiPoint      = 5
+	sdoMultiLine       = 6
+	sdoMultiPolygon    = 7
+	sdoEtypePoint      = 1
+	sdoEtypeLine       = 2
+	sdoEtypeExterior   = 1003
+	sdoEtypeInterior   = 2003
+	sdoEtypePolygon    = 3
+	sdoInterpStraight  = 1
+	sdoInterpRectangle = 3
+)
+
+var sdoTypes = map[GeometryType]int{
+	GeometryPoint: sdoPoint, GeometryLineString: sdoLine, GeometryPolygon: sdoPolygon,
+	GeometryMultiPoint: sdoMultiPoint, GeometryMultiLineString: sdoMultiLine, GeometryMultiPolygon: sdoMultiPolygon,
+	GeometryGeometryCollection: sdoCollection,
+}
+
+// toSDO converts the Geometry to the SDO_GEOMETRY representation.
+// The polygon rings are oriented as Oracle requires: the exterior ring counterclockwise,
+// the holes clockwise.
+func (g Geometry) toSDO() (sdoGeometry, error) {
+	if err := g.check(); err != nil {
+		return sdoGeometry{}, err
+	}
+	dims := g.dims()
+	s := sdoGeometry{GType: dims*1000 + sdoTypes[g.Type], SRID: g.SRID}
+	if g.Type == GeometryPoint {
+		if len(g.Coords) != 0 {
+			s.Point = []float64{g.Coords[0][0], g.Coords[0][1], math.NaN()}
+			if dims == 3 {
+				s.Point[2] = g.Coords[0][2]
+			}
+		}
+		return s, nil
+	}
+	addElem := func(etype, interp int, coords [][]float64) {
+		s.ElemInfo = append(s.ElemInfo, len(s.Ordinates)+1, etype, interp)
+		for _, c := range coords {
+			s.Ordinates = append(s.Ordinates, c...)
+		}
+	}
+	var add func(m Geometry) error
+	add = func(m Geometry) error {
+		switch m.Type {
+		case GeometryPoint:
+			if len(m.Coords) != 0 {
+				addElem(sdoEtypePoint, 1, m.Coords)
+			}
+		case GeometryLineString:
+			if len(m.Coords) != 0 {
+				addElem(sdoEtypeLine, sdoInterpStraight, m.Coords)
+			}
+		case GeometryPolygon:
+			for i, r := range m.Rings {
+				if i == 0 {
+					addElem(sdoEtypeExterior, sdoInterpStraight, orientRing(r, true))
+				} else {
+					addElem(sdoEtypeInterior, sdoInterpStraight, orientRing(r, false))
+				}
+			}
+		case GeometryMultiPoint:
+			// one point cluster
+			coords := make([][]float64, 0, len(m.Members))
+			for _, p := range m.Members {
+				coords = append(coords, p.Coords...)
+			}
+			if len(coords) != 0 {
+				addElem(sdoEtypePoint, len(coords), coords)
+			}
+		default:
+			// SDO_GEOMETRY has no nested collections, so they are flattened
+			for _, mm := range m.Members {
+				if err := add(mm); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return s, add(g)
+}
+
+// orientRing returns the ring counterclockwise (ccw) or clockwise.
+func orientRing(r [][]float64, ccw bool) [][]float64 {
+	// shoelace formula: positive area is counterclockwise
+	var area float64
+	for i := 0; i+1 < len(r); i++ {
+		area += r[i][0]*r[i+1][1] - r[i+1][0]*r[i][1]
+	}
+	if (area > 0) == ccw || area == 0 {
+		return r
+	}
+	rev := make([][]float64, len(r))
+	for i, c := range r {
+		rev[len(r)-1-i] = c
+	}
+	return rev
+}
+
+// sdoElem is an element of the SDO_ELEM_INFO.
+type sdoElem struct {
+	etype  int
+	coords [][]float64
+}
+
+// geometry converts the SDO_GEOMETRY representation to Geometry.
+func (s sdoGeometry) geometry() (Geometry, error) {
+	dims, lrs, tt := s.GType/1000, s.GType/100%10, s.GType%100
+	if dims != 2 && dims != 3 || lrs != 0 && lrs != dims {
+		return Geometry{}, errors.Errorf("SDO_GTYPE %d: %d dimensions: %w", s.GType, dims, ErrGeometry)
+	}
+	g := Geometry{SRID: s.SRID, Dims: dims}
+	if dims == 2 {
+		g.Dims = 0
+	}
+	if tt == sdoPoint && s.Point != nil {
+		g.Type = GeometryPoint
+		g.Coords = [][]float64{s.Point[:dims:dims]}
+		return g, nil
+	}
+	if len(s.ElemInfo)%3 != 0 {
+		return g, errors.Errorf("SDO_ELEM_INFO of length %d: %w", len(s.ElemInfo), ErrGeometry)
+	}
+
+	var elems []sdoElem
+	for i := 0; i < len(s.ElemInfo); i += 3 {
+		offset, etype, interp := s.ElemInfo[i], s.ElemInfo[i+1], s.ElemInfo[i+2]
+		end := len(s.Ordinates) + 1
+		if i+3 < len(s.ElemInfo) {
+			end = s.ElemInfo[i+3]
+		}
+		if offset < 1 || offset > end || end > len(s.Ordinates)+1 || (end-offset)%dims != 0 {
+			return g, errors.Errorf("SDO_ELEM_INFO offset %d (next %d, %d ordinates): %w", offset, end, len(s.Ordinates), ErrGeometry)
+		}
+		ords := s.Ordinates[offset-1 : end-1]
+		coords := make([][]float64, 0, len(ords)/dims)
+		for j := 0; j < len(ords); j += dims {
+			coords = append(coords, ords[j:j+dims:j+dims])
+		}
+		switch etype {
+		case 0:
+			continue // unsupported element, can be ignored
+		case sdoEtypePoint:
+			if interp == 0 {
+				continue // orientation of an oriented point
+			}
+		case sdoEtypeLine:
+			if interp != sdoInterpStraight {
+				return g, errors.Errorf("line with arcs (interpretation %d): %w", interp, ErrGeometry)
+			}
+		case sdoEtypeExterior, sdoEtypeInterior, sdoEtypePolygon:
+			switch interp {
+			case sdoInterpStraight:
+			case sdoInterpRectangle:
+				if len(coords) != 2 {
+					return g, errors.Errorf("rectangle of %d points: %w", len(coords), ErrGeometry)
+				}
+				coords = rectangle(coords[0], coords[1], etype != sdoEtypeInterior)
+			default:
+				return g, errors.Errorf("polygon with arcs or circle (interpretation %d): %w", interp, ErrGeometry)
+			}
+			if etype == sdoEtypePolygon {
+				etype = sdoEtypeExterior
+			}
+		default:
+			return g, errors.Errorf("compound element (etype %d): %w", etype, ErrGeometry)
+		}
+		elems = append(elems, sdoElem{etype: etype, coords: coords})
+	}
+
+	members := sdoMembers(elems)
+	switch tt {
+	case sdoPoint, sdoLine, sdoPolygon:
+		g.Type = map[int]GeometryType{sdoPoint: GeometryPoint, sdoLine: GeometryLineString, sdoPolygon: GeometryPolygon}[tt]
+		switch len(members) {
+		case 0:
+		case 1:
+			if members[0].Type != g.Type {
+				return g, errors.Errorf("SDO_GTYPE %d with %v: %w", s.GType, members[0].Type, ErrGeometry)
+			}
+			g.Coords, g.Rings = members[0].Coords, members[0].Rings
+		default:
+			return g, errors.Errorf("SDO_GTYPE %d with %d elements: %w", s.GType, len(members), ErrGeometry)
+		}
+	case sdoMultiPoint, sdoMultiLine, sdoMultiPolygon:
+		g.Type = map[int]GeometryType{sdoMultiPoint: GeometryMultiPoint, sdoMultiLine: GeometryMultiLineString, sdoMultiPolygon: GeometryMultiPolygon}[tt]
+		mt := g.Type.memberType()
+		for _, m := range members {
+			if m.Type == GeometryMultiPoint && mt == GeometryPoint {
+				g.Members = append(g.Members, m.Members...)
+				continue
+			}
+			if m.Type != mt {
+				return g, errors.Errorf("SDO_GTYPE %d with %v: %w", s.GType, m.Type, ErrGeometry)
+			}
+			g.Members = append(g.Members, m)
+		}
+	case sdoCollection:
+		g.Type, g.Members = GeometryGeometryCollection, members
+	default:
+		return g, errors.Errorf("SDO_GTYPE %d: %w", s.GType, ErrGeometry)
+	}
+	for i := range g.Members {
+		g.Members[i].Dims = g.Dims
+	}
+	return g, nil
+}
+
+// sdoMembers groups the elements into geometries:
+// a point cluster is a MultiPoint, and the holes belong to the preceding exterior ring.
+func sdoMembers(elems []sdoElem) []Geometry {
+	var members []Geometry
+	for _, e := range elems {
+		switch e.etype {
+		case sdoEtypePoint:
+			if len(e.coords) == 1 {
+				members = append(members, Geometry{Type: GeometryPoint, Coords: e.coords})
+				continue
+			}
+			mp := Geometry{Type: GeometryMultiPoint, Members: make([]Geometry, len(e.coords))}
+			for i, c := range e.coords {
+				mp.Members[i] = Geometry{Type: GeometryPoint, Coords: [][]float64{c}}
+			}
+			members = append(members, mp)
+		case sdoEtypeLine:
+			members = append(members, Geometry{Type: GeometryLineString, Coords: e.coords})
+		case sdoEtypeExterior:
+			members = append(members, Geometry{Type: GeometryPolygon, Rings: [][][]float64{e.coords}})
+		case sdoEtypeInterior:
+			if n := len(members); n != 0 && members[n-1].Type == GeometryPolygon {
+				members[n-1].Rings = append(members[n-1].Rings, e.coords)
+			} else {
+				// a hole without exterior ring
+				members = append(members, Geometry{Type: GeometryPolygon, Rings: [][][]float64{nil, e.coords}})
+			}
+		}
+	}
+	return members
+}
+
+// rectangle returns the ring of the rectangle given by its lower left and upper right corners.
+func rectangle(ll, ur []float64, ccw bool) [][]float64 {
+	corner := func(x, y []float64) []float64 {
+		c := append([]float64{x[0], y[1]}, ll[2:]...)
+		return c
+	}
+	r := [][]float64{ll, corner(ur, ll), ur, corner(ll, ur), ll}
+	return orientRing(r, ccw)
+}
+
+// isEmpty reports whether the geometry has no points.
+func (g Geometry) isEmpty() bool {
+	switch g.Type {
+	case GeometryPoint, GeometryLineString:
+		return len(g.Coords) == 0
+	case GeometryPolygon:
+		return len(g.Rings) == 0
+	}
+	return len(g.Members) == 0
+}
+
+// firstCoord returns the first point's coordinates, or nil.
+func (g Geometry) firstCoord() []float64 {
+	if len(g.Coords) != 0 {
+		return g.Coords[0]
+	}
+	for _, r := range g.Rings {
+		if len(r) != 0 {
+			return r[0]
+		}
+	}
+	for _, m := range g.Members {
+		if c := m.firstCoord(); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// setDims sets the Dims of the geometry and its members - 0 for 2 dimensions.
+func (g *Geometry) setDims(dims int) {
+	if dims == 2 {
+		dims = 0
+	}
+	g.Dims = dims
+	for i := range g.Members {
+		g.Members[i].setDims(dims)
+	}
+}
+
+// normalize sets the dimensions from the given dims, or the first coordinate,
+// and checks the geometry.
+func (g *Geometry) normalize(dims int) error {
+	if dims == 0 {
+		dims = len(g.firstCoord())
+	}
+	g.setDims(dims)
+	return g.check()
+}
+
+// Scan implements sql.Scanner.
+//
+// It accepts an SDO_GEOMETRY Object (and closes it), a WKT string or WKB bytes
+// (as SDO_UTIL.TO_WKTGEOMETRY and SDO_UTIL.TO_WKBGEOMETRY return). NULL is the zero Geometry.
+func (g *Geometry) Scan(src interface{}) error {
+	switch x := src.(type) {
+	case nil:
+		*g = Geometry{}
+	case Geometry:
+		*g = x
+	case *Object:
+		if x == nil {
+			*g = Geometry{}
+			return nil
+		}
+		defer x.Close()
+		s, err := sdoFromObject(x)
+		if err != nil {
+			return err
+		}
+		if s.GType == 0 {
+			*g = Geometry{}
+			return nil
+		}
+		gg, err := s.geometry()
+		if err != nil {
+			return err
+		}
+		*g = gg
+	case string:
+		if x == "" {
+			*g = Geometry{}
+			return nil
+		}
+		gg, err := ParseWKT(x)
+		if err != nil {
+			return err
+		}
+		*g = gg
+	case []byte:
+		if len(x) == 0 {
+			*g = Geometry{}
+			return nil
+		}
+		gg, err := ParseWKB(x)
+		if err != nil {
+			return err
+		}
+		*g = gg
+	default:
+		return errors.Errorf("cannot scan %T into Geometry", src)
+	}
+	return nil
+}
+
+// NewObject returns the Geometry as a new Object of the given type,
+// which must have the attributes of MDSYS.SDO_GEOMETRY.
+//
+// As with all Objects, you MUST call Close on it when not needed anymore!
+func (g Geometry) NewObject(typ ObjectType) (*Object, error) {
+	s, err := g.toSDO()
+	if err != nil {
+		return nil, err
+	}
+	O, err := typ.NewObject()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.setObject(O); err != nil {
+		O.Close()
+		return nil, errors.Errorf("%s: %w", typ.FullName(), err)
+	}
+	return O, nil
+}
+
+// setObject sets the attributes of the SDO_GEOMETRY Object.
+func (s sdoGeometry) setObject(O *Object) error {
+	var null Data
+	setNum := func(O *Object, name string, f float64, isNull bool) error {
+		if isNull {
+			null.reset()
+			return O.SetAttribute(name, &null)
+		}
+		return O.Set(name, f)
+	}
+	if err := setNum(O, "SDO_GTYPE", float64(s.GType), false); err != nil {
+		return errors.Errorf("SDO_GTYPE: %w", err)
+	}
+	if err := setNum(O, "SDO_SRID", float64(s.SRID), s.SRID == 0); err != nil {
+		return errors.Errorf("SDO_SRID: %w", err)
+	}
+
+	null.reset()
+	if s.Point == nil {
+		if err := O.SetAttribute("SDO_POINT", &null); err != nil {
+			return errors.Errorf("SDO_POINT: %w", err)
+		}
+	} else {
+		pt, err := O.Attributes["SDO_POINT"].NewObject()
+		if err != nil {
+			return errors.Errorf("SDO_POINT: %w", err)
+		}
+		defer pt.Close()
+		for i, name := range []string{"X", "Y", "Z"} {
+			if err = setNum(pt, name, s.Point[i], math.IsNaN(s.Point[i])); err != nil {
+				return errors.Errorf("SDO_POINT.%s: %w", name, err)
+			}
+		}
+		if err = O.Set("SDO_POINT", pt); err != nil {
+			return errors.Errorf("SDO_POINT: %w", err)
+		}
+	}
+
+	ords := make([]float64, len(s.ElemInfo))
+	for i, e := range s.ElemInfo {
+		ords[i] = float64(e)
+	}
+	for _, a := range []struct {
+		name string
+		nums []float64
+	}{{"SDO_ELEM_INFO", ords}, {"SDO_ORDINATES", s.Ordinates}} {
+		if a.nums == nil {
+			null.reset()
+			if err := O.SetAttribute(a.name, &null); err != nil {
+				return errors.Errorf("%s: %w", a.name, err)
+			}
+			continue
+		}
+		coll, err := O.Attributes[a.name].NewCollection()
+		if err != nil {
+			return errors.Errorf("%s: %w", a.name, err)
+		}
+		for _, f := range a.nums {
+			if err = coll.Append(f); err != nil {
+				coll.Close()
+				return errors.Errorf("%s: %w", a.name, err)
+			}
+		}
+		err = O.Set(a.name, coll.Object)
+		coll.Close()
+		if err != nil {
+			return errors.Errorf("%s: %w", a.name, err)
+		}
+	}
+	return nil
+}
+
+// sdoFromObject reads the attributes of the SDO_GEOMETRY Object.
+func sdoFromObject(O *Object) (sdoGeometry, error) {
+	var s sdoGeometry
+	var d Data
+	getNum := func(O *Object, name string) (float64, bool, error) {
+		if err := O.GetAttribute(&d, name); err != nil {
+			return 0, false, err
+		}
+		if d.IsNull() {
+			return 0, false, nil
+		}
+		f, err := geomFloat(d.Get())
+		return f, true, err
+	}
+	gtype, ok, err := getNum(O, "SDO_GTYPE")
+	if err != nil || !ok {
+		return s, err
+	}
+	s.GType = int(gtype)
+	srid, _, err := getNum(O, "SDO_SRID")
+	if err != nil {
+		return s, err
+	}
+	s.SRID = int(srid)
+
+	v, err := O.Get("SDO_POINT")
+	if err != nil {
+		return s, errors.Errorf("SDO_POINT: %w", err)
+	}
+	if pt, _ := v.(*Object); pt != nil {
+		defer pt.Close()
+		s.Point = []float64{0, 0, math.NaN()}
+		var isNull bool
+		for i, name := range []string{"X", "Y", "Z"} {
+			f, ok, err := getNum(pt, name)
+			if err != nil {
+				return s, errors.Errorf("SDO_POINT.%s: %w", name, err)
+			}
+			if ok {
+				s.Point[i] = f
+			} else if i < 2 {
+				isNull = true
+			}
+		}
+		if isNull {
+			s.Point = nil
+		}
+	}
+
+	for _, name := range []string{"SDO_ELEM_INFO", "SDO_ORDINATES"} {
+		v, err := O.Get(name)
+		if err != nil {
+			return s, errors.Errorf("%s: %w", name, err)
+		}
+		var coll *ObjectCollection
+		switch x := v.(type) {
+		case *ObjectCollection:
+			coll = x
+		case *Object:
+			if x != nil {
+				defer x.Close()
+			}
+		}
+		if coll == nil || coll.Object == nil {
+			continue
+		}
+		defer coll.Close()
+		var nums []float64
+		i, err := coll.First()
+		for err == nil {
+			if err = coll.GetItem(&d, i); err != nil {
+				break
+			}
+			// the arrays may be padded with NULLs
+			if !d.IsNull() {
+				f, err := geomFloat(d.Get())
+				if err != nil {
+					return s, errors.Errorf("%s[%d]: %w", name, i, err)
+				}
+				nums = append(nums, f)
+			}
+			i, err = coll.Next(i)
+		}
+		if !errors.Is(err, ErrNotExist) {
+			return s, errors.Errorf("%s: %w", name, err)
+		}
+		if name == "SDO_ORDINATES" {
+			s.Ordinates = nums
+			continue
+		}
+		s.ElemInfo = make([]int, len(nums))
+		for i, f := range nums {
+			s.ElemInfo[i] = int(f)
+		}
+	}
+	return s, nil
+}
+
+// geomFloat returns the number as float64.
+func geomFloat(v interface{}) (float64, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case float32:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case uint64:
+		return float64(x), nil
+	case []byte:
+		return strconv.ParseFloat(string(x), 64)
+	case string:
+		return strconv.ParseFloat(x, 64)
+	}
+	return 0, errors.Errorf("%T is not a number: %w", v, ErrGeometry)
+}
+
+// WKT returns the Well-Known Text representation of the geometry,
+// prefixed with "SRID=n;" (as EWKT) if SRID is not 0.
+func (g Geometry) WKT() (string, error) {
+	if err := g.check(); err != nil {
+		return "", err
+	}
+	var b []byte
+	if g.SRID != 0 {
+		b = append(append(append(b, "SRID="...), strconv.Itoa(g.SRID)...), ';')
+	}
+	return string(appendWKT(b, g)), nil
+}
+
+func appendWKT(b []byte, g Geometry) []byte {
+	b = append(b, strings.ToUpper(g.Type.String())...)
+	if g.dims() == 3 {
+		b = append(b, " Z"...)
+	}
+	return appendWKTBody(append(b, ' '), g)
+}
+
+func appendWKTBody(b []byte, g Geometry) []byte {
+	if g.isEmpty() {
+		return append(b, "EMPTY"...)
+	}
+	switch g.Type {
+	case GeometryPoint, GeometryLineString:
+		return appendWKTCoords(b, g.Coords)
+	case GeometryPolygon:
+		b = append(b, '(')
+		for i, r := range g.Rings {
+			if i != 0 {
+				b = append(b, ", "...)
+			}
+			b = appendWKTCoords(b, r)
+		}
+		return append(b, ')')
+	}
+	b = append(b, '(')
+	for i, m := range g.Members {
+		if i != 0 {
+			b = append(b, ", "...)
+		}
+		if g.Type == GeometryGeometryCollection {
+			b = appendWKT(b, m)
+		} else {
+			b = appendWKTBody(b, m)
+		}
+	}
+	return append(b, ')')
+}
+
+func appendWKTCoords(b []byte, coords [][]float64) []byte {
+	b = append(b, '(')
+	for i, c := range coords {
+		if i != 0 {
+			b = append(b, ", "...)
+		}
+		for j, f := range c {
+			if j != 0 {
+				b = append(b, ' ')
+			}
+			b = strconv.AppendFloat(b, f, 'f', -1, 64)
+		}
+	}
+	return append(b, ')')
+}
+
+// ParseWKT parses the Well-Known Text representation of a geometry.
+// The "SRID=n;" prefix of EWKT is accepted, too.
+// Measures (M and ZM geometries) are not supported.
+func ParseWKT(s string) (Geometry, error) {
+	var srid int
+	if t := strings.TrimSpace(s); len(t) > 5 && strings.EqualFold(t[:5], "SRID=") {
+		i := strings.IndexByte(t, ';')
+		if i < 0 {
+			return Geometry{}, errors.Errorf("%q: no ; after SRID: %w", s, ErrGeometry)
+		}
+		var err error
+		if srid, err = strconv.Atoi(strings.TrimSpace(t[5:i])); err != nil {
+			return Geometry{}, errors.Errorf("%q: SRID: %w", s, err)
+		}
+		s = t[i+1:]
+	}
+	p := wktParser{s: s}
+	g, err := p.geometry()
+	if err == nil {
+		if p.skipSpace(); p.pos < len(p.s) {
+			err = p.errorf("trailing characters")
+		}
+	}
+	if err != nil {
+		return Geometry{}, err
+	}
+	g.SRID = srid
+	return g, nil
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) errorf(format string, args ...interface{}) error {
+	return errors.Errorf("WKT %q at %d: %s: %w", p.s, p.pos, fmt.Sprintf(format, args...), ErrGeometry)
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character, or 0 at the end.
+func (p *wktParser) peek() byte {
+	if p.skipSpace(); p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// word returns the next (uppercased) word, and consumes it iff consume is true.
+func (p *wktParser) word(consume bool) string {
+	p.skipSpace()
+	end := p.pos
+	for end < len(p.s) && ('a' <= p.s[end]|0x20 && p.s[end]|0x20 <= 'z') {
+		end++
+	}
+	w := strings.ToUpper(p.s[p.pos:end])
+	if consume {
+		p.pos = end
+	}
+	return w
+}
+
+func (p *wktParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("wanted %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// list parses a parenthesized, comma-separated list, calling item for each element.
+func (p *wktParser) list(item func() error) error {
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if p.peek() != ',' {
+			return p.expect(')')
+		}
+		p.pos++
+	}
+}
+
+func (p *wktParser) coord() ([]float64, error) {
+	var c []float64
+	for {
+		p.skipSpace()
+		end := p.pos
+		for end < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[end]) >= 0 {
+			end++
+		}
+		if end == p.pos {
+			break
+		}
+		f, err := strconv.ParseFloat(p.s[p.pos:end], 64)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.pos = end
+		c = append(c, f)
+	}
+	if len(c) < 2 {
+		return nil, p.errorf("wanted coordinates")
+	}
+	return c, nil
+}
+
+func (p *wktParser) coords() ([][]float64, error) {
+	var coords [][]float64
+	err := p.list(func() error {
+		c, err := p.coord()
+		coords = append(coords, c)
+		return err
+	})
+	return coords, err
+}
+
+// empty consumes the EMPTY keyword, if it is the next word.
+func (p *wktParser) empty() bool {
+	if p.word(false) != "EMPTY" {
+		return false
+	}
+	p.word(true)
+	return true
+}
+
+func (p *wktParser) geometry() (Geometry, error) {
+	var g Geometry
+	name := p.word(true)
+	for t, s := range geometryTypeNames {
+		if s != "" && strings.ToUpper(s) == name {
+			g.Type = GeometryType(t)
+			break
+		}
+	}
+	if g.Type == 0 {
+		return g, p.errorf("unknown geometry type %q", name)
+	}
+	var dims int
+	switch p.word(false) {
+	case "Z":
+		p.word(true)
+		dims = 3
+	case "M", "ZM":
+		return g, p.errorf("measures are not supported")
+	}
+	if !p.empty() {
+		if err := p.body(&g); err != nil {
+			return g, err
+		}
+	}
+	return g, g.normalize(dims)
+}
+
+func (p *wktParser) body(g *Geometry) error {
+	var err error
+	switch g.Type {
+	case GeometryPoint, GeometryLineString:
+		g.Coords, err = p.coords()
+	case GeometryPolygon:
+		err = p.list(func() error {
+			r, err := p.coords()
+			g.Rings = append(g.Rings, r)
+			return err
+		})
+	case GeometryGeometryCollection:
+		err = p.list(func() error {
+			m, err := p.geometry()
+			g.Members = append(g.Members, m)
+			return err
+		})
+	default:
+		mt := g.Type.memberType()
+		err = p.list(func() error {
+			m := Geometry{Type: mt}
+			switch {
+			case p.empty():
+			case mt == GeometryPoint && p.peek() != '(':
+				// MULTIPOINT (1 2, 3 4)
+				c, err := p.coord()
+				if err != nil {
+					return err
+				}
+				m.Coords = [][]float64{c}
+			default:
+				if err := p.body(&m); err != nil {
+					return err
+				}
+			}
+			g.Members = append(g.Members, m)
+			return nil
+		})
+	}
+	return err
+}
+
+// wkbZ is the ISO WKB type code offset of the geometries with Z coordinates.
+const wkbZ = 1000
+
+// The EWKB (PostGIS) type code flags.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// WKB returns the (ISO, little endian) Well-Known Binary representation of the geometry.
+// The SRID is not part of it.
+func (g Geometry) WKB() ([]byte, error) {
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+	return appendWKB(nil, g), nil
+}
+
+func appendWKB(b []byte, g Geometry) []byte {
+	var a [8]byte
+	putUint32 := func(u uint32) {
+		binary.LittleEndian.PutUint32(a[:4], u)
+		b = append(b, a[:4]...)
+	}
+	putCoords := func(coords [][]float64) {
+		for _, c := range coords {
+			for _, f := range c {
+				binary.LittleEndian.PutUint64(a[:], math.Float64bits(f))
+				b = append(b, a[:]...)
+			}
+		}
+	}
+	b = append(b, 1) // little endian
+	typ := uint32(g.Type)
+	if g.dims() == 3 {
+		typ += wkbZ
+	}
+	putUint32(typ)
+	switch g.Type {
+	case GeometryPoint:
+		if len(g.Coords) == 0 {
+			// the empty point is NaNs
+			nan := make([]float64, g.dims())
+			for i := range nan {
+				nan[i] = math.NaN()
+			}
+			putCoords([][]float64{nan})
+		} else {
+			putCoords(g.Coords)
+		}
+	case GeometryLineString:
+		putUint32(uint32(len(g.Coords)))
+		putCoords(g.Coords)
+	case GeometryPolygon:
+		putUint32(uint32(len(g.Rings)))
+		for _, r := range g.Rings {
+			putUint32(uint32(len(r)))
+			putCoords(r)
+		}
+	default:
+		putUint32(uint32(len(g.Members)))
+		for _, m := range g.Members {
+			b = appendWKB(b, m)
+		}
+	}
+	return b
+}
+
+// ParseWKB parses the Well-Known Binary representation of a geometry,
+// in both byte orders. The Z and SRID flags of EWKB (PostGIS) are accepted, too.
+// Measures (M and ZM geometries) are not supported.
+func ParseWKB(b []byte) (Geometry, error) {
+	p := wkbParser{b: b}
+	g, err := p.geometry(true)
+	if err == nil && p.pos != len(p.b) {
+		err = p.errorf("%d trailing bytes", len(p.b)-p.pos)
+	}
+	if err != nil {
+		return Geometry{}, err
+	}
+	return g, nil
+}
+
+type wkbParser struct {
+	b     []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (p *wkbParser) errorf(format string, args ...interface{}) error {
+	return errors.Errorf("WKB at %d: %s: %w", p.pos, fmt.Sprintf(format, args...), ErrGeometry)
+}
+
+func (p *wkbParser) uint32() (uint32, error) {
+	if len(p.b)-p.pos < 4 {
+		return 0, p.errorf("too short")
+	}
+	u := p.order.Uint32(p.b[p.pos:])
+	p.pos += 4
+	return u, nil
+}
+
+func (p *wkbParser) coords(n, dims int) ([][]float64, error) {
+	if (len(p.b)-p.pos)/(8*dims) < n {
+		return nil, p.errorf("too short for %d points", n)
+	}
+	coords := make([][]float64, n)
+	for i := range coords {
+		c := make([]float64, dims)
+		for j := range c {
+			c[j] = math.Float64frombits(p.order.Uint64(p.b[p.pos:]))
+			p.pos += 8
+		}
+		coords[i] = c
+	}
+	return coords, nil
+}
+
+func (p *wkbParser) geometry(top bool) (Geometry, error) {
+	var g Geometry
+	if p.pos >= len(p.b) {
+		return g, p.errorf("too short")
+	}
+	switch p.b[p.pos] {
+	case 0:
+		p.order = binary.BigEndian
+	case 1:
+		p.order = binary.LittleEndian
+	default:
+		return g, p.errorf("unknown byte order %d", p.b[p.pos])
+	}
+	p.pos++
+	typ, err := p.uint32()
+	if err != nil {
+		return g, err
+	}
+	dims := 2
+	if typ&ewkbZ != 0 {
+		dims = 3
+	}
+	if typ&ewkbM != 0 {
+		return g, p.errorf("measures are not supported")
+	}
+	if typ&ewkbSRID != 0 {
+		srid, err := p.uint32()
+		if err != nil {
+			return g, err
+		}
+		if top {
+			g.SRID = int(srid)
+		}
+	}
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+	switch typ / wkbZ {
+	case 0:
+	case 1:
+		dims = 3
+	default:
+		return g, p.errorf("measures are not supported")
+	}
+	g.Type = GeometryType(typ % wkbZ)
+	if g.Type == 0 || int(g.Type) >= len(geometryTypeNames) {
+		return g, p.errorf("unknown geometry type %d", typ)
+	}
+
+	if g.Type == GeometryPoint {
+		if g.Coords, err = p.coords(1, dims); err != nil {
+			return g, err
+		}
+		if math.IsNaN(g.Coords[0][0]) && math.IsNaN(g.Coords[0][1]) {
+			g.Coords = nil
+		}
+		g.setDims(dims)
+		return g, nil
+	}
+	n, err := p.uint32()
+	if err != nil {
+		return g, err
+	}
+	switch g.Type {
+	case GeometryLineString:
+		g.Coords, err = p.coords(int(n), dims)
+	case GeometryPolygon:
+		for i := uint32(0); i < n && err == nil; i++ {
+			var m uint32
+			if m, err = p.uint32(); err == nil {
+				var r [][]float64
+				r, err = p.coords(int(m), dims)
+				g.Rings = append(g.Rings, r)
+			}
+		}
+	default:
+		for i := uint32(0); i < n && err == nil; i++ {
+			var m Geometry
+			m, err = p.geometry(false)
+			g.Members = append(g.Members, m)
+		}
+	}
+	if err != nil {
+		return g, err
+	}
+	g.setDims(dims)
+	return g, g.check()
+}
+
+// geoJSON is the JSON structure of a GeoJSON geometry.
+type geoJSON struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates,omitempty"`
+	Geometries  interface{} `json:"geometries,omitempty"`
+	CRS         *geoJSONCRS `json:"crs,omitempty"`
+}
+
+// geoJSONCRS is the named coordinate reference system of the 2008 GeoJSON specification.
+type geoJSONCRS struct {
+	Type       string `json:"type"`
+	Properties struct {
+		Name string `json:"name"`
+	} `json:"properties"`
+}
+
+// MarshalJSON implements json.Marshaler, returning the GeoJSON geometry object.
+//
+// A non-zero SRID is marshaled as a named "crs" member ("EPSG:4326"), as the 2008 GeoJSON
+// specification had it. The zero Geometry is marshaled as null.
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	if g.IsNull() {
+		return []byte("null"), nil
+	}
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+	gj := g.geoJSON()
+	if g.SRID != 0 {
+		gj.CRS = &geoJSONCRS{Type: "name"}
+		gj.CRS.Properties.Name = "EPSG:" + strconv.Itoa(g.SRID)
+	}
+	return json.Marshal(gj)
+}
+
+func (g Geometry) geoJSON() geoJSON {
+	gj := geoJSON{Type: g.Type.String()}
+	coords := func(cs [][]float64) [][]float64 {
+		if cs == nil {
+			return [][]float64{}
+		}
+		return cs
+	}
+	rings := func(rs [][][]float64) [][][]float64 {
+		if rs == nil {
+			return [][][]float64{}
+		}
+		return rs
+	}
+	switch g.Type {
+	case GeometryPoint:
+		if len(g.Coords) == 0 {
+			gj.Coordinates = []float64{}
+		} else {
+			gj.Coordinates = g.Coords[0]
+		}
+	case GeometryLineString:
+		gj.Coordinates = coords(g.Coords)
+	case GeometryPolygon:
+		gj.Coordinates = rings(g.Rings)
+	case GeometryMultiPoint:
+		cs := make([][]float64, 0, len(g.Members))
+		for _, m := range g.Members {
+			cs = append(cs, m.Coords...)
+		}
+		gj.Coordinates = cs
+	case GeometryMultiLineString:
+		rs := make([][][]float64, len(g.Members))
+		for i, m := range g.Members {
+			rs[i] = coords(m.Coords)
+		}
+		gj.Coordinates = rs
+	case GeometryMultiPolygon:
+		ps := make([][][][]float64, len(g.Members))
+		for i, m := range g.Members {
+			ps[i] = rings(m.Rings)
+		}
+		gj.Coordinates = ps
+	case GeometryGeometryCollection:
+		gs := make([]geoJSON, len(g.Members))
+		for i, m := range g.Members {
+			gs[i] = m.geoJSON()
+		}
+		gj.Geometries = gs
+	}
+	return gj
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a GeoJSON geometry object.
+//
+// The SRID is set from a named "crs" member (such as "EPSG:4326" or "urn:ogc:def:crs:EPSG::4326").
+func (g *Geometry) UnmarshalJSON(b []byte) error {
+	if s := strings.TrimSpace(string(b)); s == "null" {
+		*g = Geometry{}
+		return nil
+	}
+	gg, err := parseGeoJSON(b)
+	if err != nil {
+		return err
+	}
+	if err = gg.normalize(0); err != nil {
+		return err
+	}
+	*g = gg
+	return nil
+}
+
+func parseGeoJSON(b []byte) (Geometry, error) {
+	var gj struct {
+		Type        string            `json:"type"`
+		Coordinates json.RawMessage   `json:"coordinates"`
+		Geometries  []json.RawMessage `json:"geometries"`
+		CRS         *geoJSONCRS       `json:"crs"`
+	}
+	var g Geometry
+	if err := json.Unmarshal(b, &gj); err != nil {
+		return g, errors.Errorf("GeoJSON: %w", err)
+	}
+	for t, s := range geometryTypeNames {
+		if s != "" && s == gj.Type {
+			g.Type = GeometryType(t)
+			break
+		}
+	}
+	if g.Type == 0 {
+		return g, errors.Errorf("GeoJSON type %q: %w", gj.Type, ErrGeometry)
+	}
+	if gj.CRS != nil {
+		name := gj.CRS.Properties.Name
+		if i := strings.LastIndexByte(name, ':'); i >= 0 && strings.Contains(strings.ToUpper(name), "EPSG") {
+			g.SRID, _ = strconv.Atoi(name[i+1:])
+		}
+	}
+	if g.Type == GeometryGeometryCollection {
+		for _, raw := range gj.Geometries {
+			m, err := parseGeoJSON(raw)
+			if err != nil {
+				return g, err
+			}
+			m.SRID = 0
+			g.Members = append(g.Members, m)
+		}
+		return g, nil
+	}
+	if len(gj.Coordinates) == 0 || string(gj.Coordinates) == "null" {
+		return g, nil
+	}
+	var err error
+	switch g.Type {
+	case GeometryPoint:
+		var c []float64
+		if err = json.Unmarshal(gj.Coordinates, &c); err == nil && len(c) != 0 {
+			g.Coords = [][]float64{c}
+		}
+	case GeometryLineString:
+		err = json.Unmarshal(gj.Coordinates, &g.Coords)
+	case GeometryPolygon:
+		err = json.Unmarshal(gj.Coordinates, &g.Rings)
+	case GeometryMultiPoint:
+		var cs [][]float64
+		err = json.Unmarshal(gj.Coordinates, &cs)
+		for _, c := range cs {
+			g.Members = append(g.Members, Geometry{Type: GeometryPoint, Coords: [][]float64{c}})
+		}
+	case GeometryMultiLineString:
+		var ls [][][]float64
+		err = json.Unmarshal(gj.Coordinates, &ls)
+		for _, l := range ls {
+			g.Members = append(g.Members, Geometry{Type: GeometryLineString, Coords: l})
+		}
+	case GeometryMultiPolygon:
+		var ps [][][][]float64
+		err = json.Unmarshal(gj.Coordinates, &ps)
+		for _, p := range ps {
+			g.Members = append(g.Members, Geometry{Type: GeometryPolygon, Rings: p})
+		}
+	}
+	if err != nil {
+		return g, errors.Errorf("GeoJSON %s coordinates: %w", gj.Type, err)
+	}
+	return g, nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+var testGeometries = []struct {
+	WKT string
+	SDO sdoGeometry
+}{
+	{WKT: "POINT (1 2)", SDO: sdoGeometry{GType: 2001, Point: []float64{1, 2, math.NaN()}}},
+	{WKT: "SRID=4326;POINT Z (19.05 47.5 120.5)", SDO: sdoGeometry{GType: 3001, SRID: 4326, Point: []float64{19.05, 47.5, 120.5}}},
+	{WKT: "POINT EMPTY", SDO: sdoGeometry{GType: 2001}},
+	{WKT: "LINESTRING (0 0, 1 1, 2 0)", SDO: sdoGeometry{GType: 2002,
+		ElemInfo: []int{1, 2, 1}, Ordinates: []float64{0, 0, 1, 1, 2, 0}}},
+	{WKT: "POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 4, 4 4, 4 2, 2 2))", SDO: sdoGeometry{GType: 2003,
+		ElemInfo:  []int{1, 1003, 1, 11, 2003, 1},
+		Ordinates: []float64{0, 0, 10, 0, 10, 10, 0, 10, 0, 0, 2, 2, 2, 4, 4, 4, 4, 2, 2, 2}}},
+	{WKT: "MULTIPOINT ((1 2), (3 4))", SDO: sdoGeometry{GType: 2005,
+		ElemInfo: []int{1, 1, 2}, Ordinates: []float64{1, 2, 3, 4}}},
+	{WKT: "MULTILINESTRING Z ((0 0 1, 1 1 1), (2 2 2, 3 3 2))", SDO: sdoGeometry{GType: 3006,
+		ElemInfo: []int{1, 2, 1, 7, 2, 1}, Ordinates: []float64{0, 0, 1, 1, 1, 1, 2, 2, 2, 3, 3, 2}}},
+	{WKT: "SRID=8307;MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), ((5 5, 9 5, 9 9, 5 9, 5 5), (6 6, 6 7, 7 7, 6 6)))", SDO: sdoGeometry{GType: 2007, SRID: 8307,
+		ElemInfo: []int{1, 1003, 1, 9, 1003, 1, 19, 2003, 1},
+		Ordinates: []float64{0, 0, 1, 0, 1, 1, 0, 0,
+			5, 5, 9, 5, 9, 9, 5, 9, 5, 5,
+			6, 6, 6, 7, 7, 7, 6, 6}}},
+	{WKT: "GEOMETRYCOLLECTION (POINT (1 2), LINESTRING (0 0, 1 1), POLYGON ((0 0, 1 0, 1 1, 0 0)))", SDO: sdoGeometry{GType: 2004,
+		ElemInfo:  []int{1, 1, 1, 3, 2, 1, 7, 1003, 1},
+		Ordinates: []float64{1, 2, 0, 0, 1, 1, 0, 0, 1, 0, 1, 1, 0, 0}}},
+}
+
+func TestGeometryWKT(t *testing.T) {
+	for _, tc := range testGeometries {
+		g, err := ParseWKT(tc.WKT)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		got, err := g.WKT()
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		if got != tc.WKT {
+			t.Errorf("got %q, wanted %q", got, tc.WKT)
+		}
+	}
+
+	for in, want := range map[string]string{
+		"multipoint(1 2,3 4)":                       "MULTIPOINT ((1 2), (3 4))",
+		" Point Z(1 2 3) ":                          "POINT Z (1 2 3)",
+		"srid=3857; LineString (1e3 -2.5, 0 0)":     "SRID=3857;LINESTRING (1000 -2.5, 0 0)",
+		"GEOMETRYCOLLECTION (POINT EMPTY)":          "GEOMETRYCOLLECTION (POINT EMPTY)",
+		"MULTIPOLYGON (EMPTY, ((0 0,1 0,1 1,0 0)))": "MULTIPOLYGON (EMPTY, ((0 0, 1 0, 1 1, 0 0)))",
+	} {
+		g, err := ParseWKT(in)
+		if err != nil {
+			t.Fatalf("%s: %+v", in, err)
+		}
+		if got, err := g.WKT(); err != nil || got != want {
+			t.Errorf("%q: got %q (%v), wanted %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{
+		"", "POINT", "POINT (1)", "POINT M (1 2 3)", "CIRCLE (1 2)", "LINESTRING (1 2)",
+		"POINT Z (1 2)", "LINESTRING (0 0, 1 1 1)", "POLYGON ((0 0, 1 1, 0 0))", "POINT (1 2) x",
+		"SRID=x;POINT (1 2)",
+	} {
+		if g, err := ParseWKT(in); err == nil {
+			t.Errorf("%q: parsed as %+v", in, g)
+		}
+	}
+}
+
+func TestGeometryWKB(t *testing.T) {
+	for _, tc := range testGeometries {
+		g, err := ParseWKT(tc.WKT)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		b, err := g.WKB()
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		got, err := ParseWKB(b)
+		if err != nil {
+			t.Fatalf("%s: %x: %+v", tc.WKT, b, err)
+		}
+		got.SRID = g.SRID
+		if !reflect.DeepEqual(got, g) {
+			t.Errorf("%s: got %+v, wanted %+v", tc.WKT, got, g)
+		}
+	}
+
+	for in, want := range map[string]string{
+		"0101000000000000000000f03f0000000000000040": "POINT (1 2)",
+		// big endian, ISO Z
+		"00000003e93ff000000000000040000000000000004008000000000000": "POINT Z (1 2 3)",
+		// EWKB with SRID and Z
+		"01010000a0e6100000000000000000f03f00000000000000400000000000000840":                                     "SRID=4326;POINT Z (1 2 3)",
+		"0101000000000000000000f87f000000000000f87f":                                                             "POINT EMPTY",
+		"010400000002000000010100000000000000000000000000000000000000010100000000000000000000000000000000000000": "MULTIPOINT ((0 0), (0 0))",
+	} {
+		b, err := hex.DecodeString(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := ParseWKB(b)
+		if err != nil {
+			t.Fatalf("%s: %+v", in, err)
+		}
+		if got, err := g.WKT(); err != nil || got != want {
+			t.Errorf("%s: got %q (%v), wanted %q", in, got, err, want)
+		}
+	}
+	if b, err := (Geometry{Type: GeometryPoint, Coords: [][]float64{{1, 2}}}).WKB(); err != nil || hex.EncodeToString(b) != "0101000000000000000000f03f0000000000000040" {
+		t.Errorf("got %x (%v)", b, err)
+	}
+
+	for _, in := range []string{
+		"", "02", "0101000000", "01d1070000000000000000f03f00000000000000400000000000000840",
+		"010200000005000000000000000000f03f",
+		"0101000000000000000000f03f000000000000004000",
+	} {
+		b, _ := hex.DecodeString(in)
+		if g, err := ParseWKB(b); err == nil {
+			t.Errorf("%s: parsed as %+v", in, g)
+		}
+	}
+}
+
+func TestGeometryGeoJSON(t *testing.T) {
+	for _, tc := range testGeometries {
+		g, err := ParseWKT(tc.WKT)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		b, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		var got Geometry
+		if err = json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("%s: %s: %+v", tc.WKT, b, err)
+		}
+		if !reflect.DeepEqual(got, g) {
+			t.Errorf("%s: %s: got %+v, wanted %+v", tc.WKT, b, got, g)
+		}
+	}
+
+	for in, want := range map[string]string{
+		`{"type":"Point","coordinates":[19.05,47.5],"crs":{"type":"name","properties":{"name":"urn:ogc:def:crs:EPSG::4326"}}}`: "SRID=4326;POINT (19.05 47.5)",
+		`{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`:                                                                    "MULTIPOINT ((1 2), (3 4))",
+		`{"type":"GeometryCollection","geometries":[{"type":"LineString","coordinates":[[1,2,3],[4,5,6]]}]}`:                   "GEOMETRYCOLLECTION Z (LINESTRING Z (1 2 3, 4 5 6))",
+	} {
+		var g Geometry
+		if err := json.Unmarshal([]byte(in), &g); err != nil {
+			t.Fatalf("%s: %+v", in, err)
+		}
+		if got, err := g.WKT(); err != nil || got != want {
+			t.Errorf("%s: got %q (%v), wanted %q", in, got, err, want)
+		}
+	}
+
+	g, _ := ParseWKT("SRID=4326;POLYGON ((0 0, 1 0, 1 1, 0 0))")
+	if b, err := json.Marshal(g); err != nil || string(b) != `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]],"crs":{"type":"name","properties":{"name":"EPSG:4326"}}}` {
+		t.Errorf("got %s (%v)", b, err)
+	}
+	if b, err := json.Marshal(struct{ G Geometry }{}); err != nil || string(b) != `{"G":null}` {
+		t.Errorf("got %s (%v)", b, err)
+	}
+
+	for _, in := range []string{
+		`{"type":"Feature"}`, `{"type":"Point","coordinates":[1]}`, `{"type":"LineString","coordinates":1}`, `[]`,
+	} {
+		var g Geometry
+		if err := json.Unmarshal([]byte(in), &g); err == nil {
+			t.Errorf("%s: unmarshaled as %+v", in, g)
+		}
+	}
+}
+
+func TestGeometrySDO(t *testing.T) {
+	for _, tc := range testGeometries {
+		g, err := ParseWKT(tc.WKT)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		s, err := g.toSDO()
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		// compare the printed forms, as NaN != NaN
+		if fmt.Sprintf("%+v", s) != fmt.Sprintf("%+v", tc.SDO) {
+			t.Errorf("%s: got %+v, wanted %+v", tc.WKT, s, tc.SDO)
+		}
+		got, err := tc.SDO.geometry()
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.WKT, err)
+		}
+		if !reflect.DeepEqual(got, g) {
+			t.Errorf("%s: got %+v, wanted %+v", tc.WKT, got, g)
+		}
+	}
+
+	for _, tc := range []struct {
+		SDO  sdoGeometry
+		Want string
+	}{
+		{SDO: sdoGeometry{GType: 2003}, Want: "POLYGON EMPTY"},
+		// the oriented point of TestSDO (with the trailing NULLs skipped)
+		{SDO: sdoGeometry{GType: 3001, ElemInfo: []int{1, 1, 1, 4, 1, 0},
+			Ordinates: []float64{480736.567, 10853969.692, 0, 0.998807402795312, -0.0488238888381834, 0}},
+			Want: "POINT Z (480736.567 10853969.692 0)"},
+		// optimized rectangle
+		{SDO: sdoGeometry{GType: 2003, SRID: 8307, ElemInfo: []int{1, 1003, 3}, Ordinates: []float64{1, 1, 5, 7}},
+			Want: "SRID=8307;POLYGON ((1 1, 5 1, 5 7, 1 7, 1 1))"},
+		// point in the ordinates, not in SDO_POINT
+		{SDO: sdoGeometry{GType: 2001, ElemInfo: []int{1, 1, 1}, Ordinates: []float64{3, 4}}, Want: "POINT (3 4)"},
+		{SDO: sdoGeometry{GType: 2005, ElemInfo: []int{1, 1, 1, 3, 1, 1}, Ordinates: []float64{3, 4, 5, 6}}, Want: "MULTIPOINT ((3 4), (5 6))"},
+	} {
+		g, err := tc.SDO.geometry()
+		if err != nil {
+			t.Fatalf("%+v: %+v", tc.SDO, err)
+		}
+		if got, err := g.WKT(); err != nil || got != tc.Want {
+			t.Errorf("%+v: got %q (%v), wanted %q", tc.SDO, got, err, tc.Want)
+		}
+	}
+
+	// the rings are oriented as SDO_GEOMETRY requires
+	g, _ := ParseWKT("POLYGON ((0 0, 0 10, 10 10, 10 0, 0 0), (2 2, 4 2, 4 4, 2 4, 2 2))")
+	s, err := g.toSDO()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0, 0, 10, 0, 10, 10, 0, 10, 0, 0, 2, 2, 2, 4, 4, 4, 4, 2, 2, 2}; !reflect.DeepEqual(s.Ordinates, want) {
+		t.Errorf("got %v, wanted %v", s.Ordinates, want)
+	}
+
+	for _, s := range []sdoGeometry{
+		{GType: 4001, Point: []float64{1, 2, 3}},
+		{GType: 2002, ElemInfo: []int{1, 2, 2}, Ordinates: []float64{0, 0, 1, 1, 2, 0}},
+		{GType: 2003, ElemInfo: []int{1, 1003, 4}, Ordinates: []float64{0, 0, 1, 1, 2, 0}},
+		{GType: 2002, ElemInfo: []int{1, 4, 2, 1, 2, 1, 3, 2, 2}, Ordinates: []float64{0, 0, 1, 1, 2, 0}},
+		{GType: 2002, ElemInfo: []int{1, 2}, Ordinates: []float64{0, 0, 1, 1}},
+		{GType: 2002, ElemInfo: []int{2, 2, 1}, Ordinates: []float64{0, 0, 1, 1}},
+		{GType: 2008, ElemInfo: []int{1, 2, 1}, Ordinates: []float64{0, 0, 1, 1}},
+	} {
+		if g, err := s.geometry(); err == nil {
+			t.Errorf("%+v: converted to %+v", s, g)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+// JSON is a JSON document, for the VARCHAR2, CLOB and BLOB columns with IS JSON constraint.
+//
+// As an argument, Value is marshaled (in memory) with encoding/json, and bound as a string
+// (as []byte if IsBlob), or copied into a temporary CLOB (BLOB) if longer than 32767 bytes.
+// A nil Value is bound as NULL. []JSON can be used for array DML.
+//
+// As a Scan destination (&JSON{Value: &v}), the column is unmarshaled into Value -
+// reading the LOB directly, with the LobAsReader option.
+// The JSON numbers are decoded as json.Number into interface{}, []interface{} and map[string]interface{},
+// and Number fields accept them, too, so no precision is lost - and the document marshals back the same.
+type JSON struct {
+	Value interface{}
+	// IsBlob is for binding into BLOB columns.
+	IsBlob bool
+}
+
+// bindValue returns the marshaled document as string, []byte or Lob.
+func (j JSON) bindValue() (interface{}, error) {
+	if j.Value == nil {
+		if j.IsBlob {
+			return []byte(nil), nil
+		}
+		return "", nil
+	}
+	b, err := json.Marshal(j.Value)
+	if err != nil {
+		return nil, errors.Errorf("marshal %T: %w", j.Value, err)
+	}
+	if len(b) > maxVarcharBind {
+		return Lob{Reader: bytes.NewReader(b), IsClob: !j.IsBlob}, nil
+	}
+	if j.IsBlob {
+		return b, nil
+	}
+	return string(b), nil
+}
+
+// jsonBindValues returns the marshaled documents as []string, [][]byte,
+// or []Lob if any of them is long.
+func jsonBindValues(js []JSON) (interface{}, error) {
+	vals := make([]interface{}, len(js))
+	var hasLob, isBlob bool
+	for i, j := range js {
+		var err error
+		if vals[i], err = j.bindValue(); err != nil {
+			return nil, errors.Errorf("%d. element: %w", i, err)
+		}
+		_, isLob := vals[i].(Lob)
+		hasLob = hasLob || isLob
+		isBlob = isBlob || j.IsBlob
+	}
+	if hasLob {
+		lobs := make([]Lob, len(vals))
+		for i, v := range vals {
+			if js[i].Value == nil {
+				continue // NULL
+			}
+			switch x := v.(type) {
+			case Lob:
+				lobs[i] = x
+			case string:
+				lobs[i] = Lob{Reader: strings.NewReader(x), IsClob: true}
+			case []byte:
+				lobs[i] = Lob{Reader: bytes.NewReader(x)}
+			}
+		}
+		return lobs, nil
+	}
+	if isBlob {
+		bs := make([][]byte, len(vals))
+		for i, v := range vals {
+			switch x := v.(type) {
+			case []byte:
+				bs[i] = x
+			case string:
+				bs[i] = []byte(x)
+			}
+		}
+		return bs, nil
+	}
+	ss := make([]string, len(vals))
+	for i, v := range vals {
+		ss[i], _ = v.(string)
+	}
+	return ss, nil
+}
+
+// Scan implements sql.Scanner, unmarshaling the column into Value.
+// NULL is unmarshaled as JSON null.
+func (j *JSON) Scan(src interface{}) error {
+	var r io.Reader
+	switch x := src.(type) {
+	case nil:
+		r = strings.NewReader("null")
+	case string:
+		r = strings.NewReader(x)
+	case []byte:
+		r = bytes.NewReader(x)
+	case *Lob:
+		if x == nil || x.Reader == nil {
+			r = strings.NewReader("null")
+		} else {
+			r = x.Reader
+		}
+	case io.Reader:
+		r = x
+	default:
+		return errors.Errorf("cannot scan %T into JSON", src)
+	}
+	if j.Value == nil {
+		return errors.New("JSON.Value is nil, set it to a pointer")
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(j.Value); err != nil {
+		if err == io.EOF {
+			// empty string is NULL
+			return json.Unmarshal([]byte("null"), j.Value)
+		}
+		return errors.Errorf("unmarshal into %T: %w", j.Value, err)
+	}
+	return nil
+}
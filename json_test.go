@@ -0,0 +1,134 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestJSONBind(t *testing.T) {
+	long := strings.Repeat("x", maxVarcharBind)
+	for i, tc := range []struct {
+		JSON JSON
+		Want interface{}
+	}{
+		{JSON: JSON{}, Want: ""},
+		{JSON: JSON{IsBlob: true}, Want: []byte(nil)},
+		{JSON: JSON{Value: map[string]int{"a": 1}}, Want: `{"a":1}`},
+		{JSON: JSON{Value: []int{1, 2}, IsBlob: true}, Want: []byte(`[1,2]`)},
+		{JSON: JSON{Value: long}, Want: Lob{IsClob: true}},
+		{JSON: JSON{Value: long, IsBlob: true}, Want: Lob{}},
+	} {
+		got, err := tc.JSON.bindValue()
+		if err != nil {
+			t.Fatalf("%d. %+v", i, err)
+		}
+		switch want := tc.Want.(type) {
+		case string:
+			if s, ok := got.(string); !ok || s != want {
+				t.Errorf("%d. got %#v, wanted %q", i, got, want)
+			}
+		case []byte:
+			if b, ok := got.([]byte); !ok || string(b) != string(want) {
+				t.Errorf("%d. got %#v, wanted %q", i, got, want)
+			}
+		case Lob:
+			L, ok := got.(Lob)
+			if !ok || L.IsClob != want.IsClob {
+				t.Fatalf("%d. got %#v, wanted Lob{IsClob:%t}", i, got, want.IsClob)
+			}
+			b, err := ioutil.ReadAll(L)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(b) != len(long)+2 {
+				t.Errorf("%d. got %d bytes, wanted %d", i, len(b), len(long)+2)
+			}
+		}
+	}
+
+	got, err := jsonBindValues([]JSON{{Value: 1}, {}, {Value: long}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lobs, ok := got.([]Lob)
+	if !ok || len(lobs) != 3 {
+		t.Fatalf("got %#v, wanted 3 Lobs", got)
+	}
+	if lobs[1].Reader != nil || lobs[0].Reader == nil || !lobs[0].IsClob {
+		t.Errorf("got %#v", lobs)
+	}
+	if got, err = jsonBindValues([]JSON{{Value: 1}, {}}); err != nil {
+		t.Fatal(err)
+	}
+	if ss, ok := got.([]string); !ok || len(ss) != 2 || ss[0] != "1" || ss[1] != "" {
+		t.Errorf("got %#v", got)
+	}
+}
+
+func TestJSONScan(t *testing.T) {
+	const doc = `{"amount": 12345678901234567890.123456789, "items": [1.5, {"n": 3}]}`
+	var m map[string]interface{}
+	for _, src := range []interface{}{doc, []byte(doc), &Lob{Reader: strings.NewReader(doc), IsClob: true}} {
+		m = nil
+		if err := (&JSON{Value: &m}).Scan(src); err != nil {
+			t.Fatalf("%T: %+v", src, err)
+		}
+		if got := m["amount"]; got != json.Number("12345678901234567890.123456789") {
+			t.Errorf("%T: got %#v", src, got)
+		}
+		items := m["items"].([]interface{})
+		if items[0] != json.Number("1.5") || items[1].(map[string]interface{})["n"] != json.Number("3") {
+			t.Errorf("%T: got %#v", src, items)
+		}
+	}
+
+	// the scanned document binds back with the same numbers
+	b, err := JSON{Value: m}.bindValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"amount":12345678901234567890.123456789,"items":[1.5,{"n":3}]}`
+	if b != want {
+		t.Errorf("round trip: got %s, wanted %s", b, want)
+	}
+
+	var s struct {
+		Amount Number `json:"amount"`
+	}
+	if err := (&JSON{Value: &s}).Scan(doc); err != nil {
+		t.Fatal(err)
+	}
+	if s.Amount != "12345678901234567890.123456789" {
+		t.Errorf("got %q", s.Amount)
+	}
+
+	for _, src := range []interface{}{nil, ""} {
+		m = map[string]interface{}{"a": 1}
+		if err := (&JSON{Value: &m}).Scan(src); err != nil {
+			t.Fatalf("%#v: %+v", src, err)
+		}
+		if m != nil {
+			t.Errorf("%#v: got %#v, wanted nil", src, m)
+		}
+	}
+	if err := (&JSON{}).Scan(doc); err == nil {
+		t.Error("scanned into nil Value")
+	}
+}
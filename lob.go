@@ -32,6 +32,8 @@ import (
 type Lob struct {
 	io.Reader
 	IsClob bool
+	// IsNClob is for NCLOB (national character set CLOB) - it implies IsClob.
+	IsNClob bool
 }
 
 // Hijack the underlying lob reader/writer, and
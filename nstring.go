@@ -0,0 +1,111 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+/*
+#include "dpiImpl.h"
+*/
+import "C"
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+
+	errors "golang.org/x/xerrors"
+)
+
+// NString is a string in the national character set.
+//
+// An NString argument is bound as NVARCHAR2 (which works for NCHAR, too),
+// so the database converts it to the national character set directly,
+// not through the database character set, which may not be able to represent it.
+//
+// For NCLOB, use Lob with IsNClob.
+type NString string
+
+// EncodingInfo is the character set information of the connection.
+type EncodingInfo struct {
+	// Encoding and NEncoding are the (IANA) names of the encoding used for CHAR and NCHAR data
+	// between the client and the database - goracle always uses UTF-8 for both.
+	Encoding, NEncoding string
+	// MaxBytesPerCharacter and NMaxBytesPerCharacter are the maximum number of bytes
+	// a character needs in Encoding and NEncoding.
+	MaxBytesPerCharacter, NMaxBytesPerCharacter int
+	// DBCharset and DBNCharset are the database and national character sets
+	// of the database (such as WE8ISO8859P1 and AL16UTF16).
+	// A character which is not representable in DBCharset is silently replaced
+	// when stored in a CHAR, VARCHAR2 or CLOB column - use NString and NCLOB for those.
+	DBCharset, DBNCharset string
+}
+
+// EncodingInfo returns the character set information of the connection.
+func (c *conn) EncodingInfo() (EncodingInfo, error) {
+	c.RLock()
+	var ei C.dpiEncodingInfo
+	if C.dpiConn_getEncodingInfo(c.dpiConn, &ei) == C.DPI_FAILURE {
+		c.RUnlock()
+		return EncodingInfo{}, errors.Errorf("getEncodingInfo: %w", c.getError())
+	}
+	info := EncodingInfo{
+		Encoding:              C.GoString(ei.encoding),
+		MaxBytesPerCharacter:  int(ei.maxBytesPerCharacter),
+		NEncoding:             C.GoString(ei.nencoding),
+		NMaxBytesPerCharacter: int(ei.nmaxBytesPerCharacter),
+	}
+	info.DBCharset, info.DBNCharset = c.dbCharset, c.dbNCharset
+	c.RUnlock()
+
+	if info.DBCharset == "" {
+		// the query takes the lock, too
+		const qry = "SELECT parameter, value FROM nls_database_parameters WHERE parameter IN ('NLS_CHARACTERSET', 'NLS_NCHAR_CHARACTERSET')"
+		names, err := c.queryParams(context.Background(), qry)
+		if err != nil {
+			return info, errors.Errorf("%s: %w", qry, err)
+		}
+		info.DBCharset, info.DBNCharset = names["NLS_CHARACTERSET"], names["NLS_NCHAR_CHARACTERSET"]
+		c.Lock()
+		c.dbCharset, c.dbNCharset = info.DBCharset, info.DBNCharset
+		c.Unlock()
+	}
+	return info, nil
+}
+
+// queryParams returns the first column - second column map of the query.
+func (c *conn) queryParams(ctx context.Context, qry string) (map[string]string, error) {
+	st, err := c.PrepareContext(ctx, qry)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	rows, err := st.(*statement).QueryContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	params := make(map[string]string)
+	vals := []driver.Value{nil, nil}
+	for {
+		if err = rows.Next(vals); err != nil {
+			if err == io.EOF {
+				return params, nil
+			}
+			return params, err
+		}
+		k, _ := vals[0].(string)
+		v, _ := vals[1].(string)
+		params[k] = v
+	}
+}
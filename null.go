@@ -0,0 +1,163 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+/*
+#include "dpiImpl.h"
+*/
+import "C"
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	intervalYMType = reflect.TypeOf(IntervalYM{})
+	nullInt64Type  = reflect.TypeOf(sql.NullInt64{})
+	nullFloatType  = reflect.TypeOf(sql.NullFloat64{})
+)
+
+// nullableBaseType returns the base type of the nullable type:
+// T for *T (T being a scalar), and the value's type for the sql.Null* types
+// (except NullInt64 and NullFloat64, which are bound directly).
+func nullableBaseType(typ reflect.Type) (reflect.Type, bool) {
+	switch typ.Kind() {
+	case reflect.Ptr:
+		switch et := typ.Elem(); et.Kind() {
+		case reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64,
+			reflect.String:
+			return et, true
+		case reflect.Struct:
+			if et == timeType || et == intervalYMType {
+				return et, true
+			}
+		}
+	case reflect.Struct:
+		if typ.PkgPath() == "database/sql" && strings.HasPrefix(typ.Name(), "Null") &&
+			typ != nullInt64Type && typ != nullFloatType &&
+			typ.NumField() == 2 && typ.Field(1).Name == "Valid" {
+			return typ.Field(0).Type, true
+		}
+	}
+	return nil, false
+}
+
+// nullableElem returns the base value of the nullable v, and whether it is NULL.
+func nullableElem(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(v.Type().Elem()), true
+		}
+		return v.Elem(), false
+	}
+	return v.Field(0), !v.Field(1).Bool()
+}
+
+// setNullableElem sets the nullable dst to base, or to NULL.
+func setNullableElem(dst, base reflect.Value, isNull bool) {
+	dst.Set(reflect.Zero(dst.Type()))
+	if isNull {
+		return
+	}
+	if dst.Kind() == reflect.Ptr {
+		p := reflect.New(base.Type())
+		p.Elem().Set(base)
+		dst.Set(p)
+		return
+	}
+	dst.Field(0).Set(base)
+	dst.Field(1).SetBool(true)
+}
+
+// nullable is for binding *T, []*T, sql.NullT and []sql.NullT values:
+// it returns the base (T or []T) value, which is to be bound instead,
+// whether the elements are NULL,
+// and the wrapper for the base type's dataGetter, for the OUT direction.
+//
+// For OUT parameters, value is the pointer to the destination,
+// and base is a pointer, too.
+func nullable(value interface{}, isOut bool) (base interface{}, nulls []bool, wrapGet func(dataGetter) dataGetter, ok bool) {
+	rv := reflect.ValueOf(value)
+	if isOut && rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	} else if isOut || !rv.IsValid() {
+		return nil, nil, nil, false
+	}
+	typ := rv.Type()
+	isSlice := typ.Kind() == reflect.Slice
+	elemType := typ
+	if isSlice {
+		elemType = typ.Elem()
+	}
+	baseType, ok := nullableBaseType(elemType)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	if !isSlice {
+		b, isNull := nullableElem(rv)
+		base, nulls = b.Interface(), []bool{isNull}
+	} else {
+		n := rv.Len()
+		// keep the capacity, as that is the size of the OUT PL/SQL arrays
+		bs := reflect.MakeSlice(reflect.SliceOf(baseType), n, rv.Cap())
+		nulls = make([]bool, n)
+		for i := 0; i < n; i++ {
+			var b reflect.Value
+			b, nulls[i] = nullableElem(rv.Index(i))
+			bs.Index(i).Set(b)
+		}
+		base = bs.Interface()
+	}
+	if isOut {
+		p := reflect.New(reflect.TypeOf(base))
+		p.Elem().Set(reflect.ValueOf(base))
+		base = p.Interface()
+	}
+
+	wrapGet = func(get dataGetter) dataGetter {
+		return func(v interface{}, data []C.dpiData) error {
+			dst := reflect.ValueOf(v).Elem()
+			if !isSlice {
+				tmp := reflect.New(baseType)
+				if err := get(tmp.Interface(), data); err != nil {
+					return err
+				}
+				setNullableElem(dst, tmp.Elem(), len(data) == 0 || data[0].isNull == 1)
+				return nil
+			}
+			tmp := reflect.New(reflect.SliceOf(baseType))
+			if err := get(tmp.Interface(), data); err != nil {
+				return err
+			}
+			bs := tmp.Elem()
+			n := bs.Len()
+			out := reflect.MakeSlice(typ, n, n)
+			for i := 0; i < n; i++ {
+				setNullableElem(out.Index(i), bs.Index(i), i < len(data) && data[i].isNull == 1)
+			}
+			dst.Set(out)
+			return nil
+		}
+	}
+	return base, nulls, wrapGet, true
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNullable(t *testing.T) {
+	i, s, now := int64(1), "a", time.Now()
+	for k, tc := range []struct {
+		Value interface{}
+		Base  interface{}
+		Nulls []bool
+	}{
+		{Value: &i, Base: int64(1), Nulls: []bool{false}},
+		{Value: (*int64)(nil), Base: int64(0), Nulls: []bool{true}},
+		{Value: (*time.Time)(nil), Base: time.Time{}, Nulls: []bool{true}},
+		{Value: &now, Base: now, Nulls: []bool{false}},
+		{Value: sql.NullString{String: "b", Valid: true}, Base: "b", Nulls: []bool{false}},
+		{Value: sql.NullBool{}, Base: false, Nulls: []bool{true}},
+		{Value: []*string{&s, nil}, Base: []string{"a", ""}, Nulls: []bool{false, true}},
+		{Value: []sql.NullString{{}, {String: "c", Valid: true}}, Base: []string{"", "c"}, Nulls: []bool{true, false}},
+		{Value: []*IntervalYM{nil, {Years: 1}}, Base: []IntervalYM{{}, {Years: 1}}, Nulls: []bool{true, false}},
+	} {
+		base, nulls, wrapGet, ok := nullable(tc.Value, false)
+		if !ok || wrapGet == nil {
+			t.Errorf("%d. %T is not nullable", k, tc.Value)
+			continue
+		}
+		if !reflect.DeepEqual(base, tc.Base) || !reflect.DeepEqual(nulls, tc.Nulls) {
+			t.Errorf("%d. got %#v %v, wanted %#v %v", k, base, nulls, tc.Base, tc.Nulls)
+		}
+
+		// as OUT, the destination and the base are pointers
+		dest := reflect.New(reflect.TypeOf(tc.Value))
+		dest.Elem().Set(reflect.ValueOf(tc.Value))
+		if base, nulls, _, ok = nullable(dest.Interface(), true); !ok {
+			t.Errorf("%d. OUT %T is not nullable", k, dest.Interface())
+			continue
+		}
+		if got := reflect.ValueOf(base).Elem().Interface(); !reflect.DeepEqual(got, tc.Base) || !reflect.DeepEqual(nulls, tc.Nulls) {
+			t.Errorf("%d. OUT got %#v %v, wanted %#v %v", k, got, nulls, tc.Base, tc.Nulls)
+		}
+	}
+
+	for _, v := range []interface{}{
+		nil, int64(1), "a", []int{1}, sql.NullInt64{}, sql.NullFloat64{},
+		&Object{}, new(driver.Rows), []byte("a"), new([]int),
+	} {
+		if _, _, _, ok := nullable(v, false); ok {
+			t.Errorf("%T should not be nullable", v)
+		}
+	}
+
+	// plain OUT destinations are not nullable
+	for _, v := range []interface{}{new(int64), new(string), new([]int), new(time.Time)} {
+		if _, _, _, ok := nullable(v, true); ok {
+			t.Errorf("OUT %T should not be nullable", v)
+		}
+	}
+
+	ints := make([]*int, 1, 10)
+	if base, _, _, _ := nullable(&ints, true); reflect.ValueOf(base).Elem().Cap() != 10 {
+		t.Errorf("capacity is lost: %d", reflect.ValueOf(base).Elem().Cap())
+	}
+}
@@ -0,0 +1,515 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+const (
+	// numberMaxDigits is the maximum number of significant digits of an Oracle NUMBER.
+	numberMaxDigits = 38
+	// the absolute value of a NUMBER is 0 or in [1e-130, 1e126),
+	// so with 0.DDD * 10^exp, exp is in [-129, 126].
+	numberMinExp, numberMaxExp = -129, 126
+	// numberFloatPrec is the precision of the big.Float returned by Number.BigFloat,
+	// enough for 38 decimal digits.
+	numberFloatPrec = 128
+)
+
+var (
+	// ErrNumberInfinite is returned when the overflow markers (~ and -~) should be converted to a finite number.
+	ErrNumberInfinite = errors.New("infinite number")
+	// ErrNumberRange is returned for numbers out of the range of Oracle NUMBER (1e-130 <= |x| < 1e126).
+	ErrNumberRange = errors.New("number out of range")
+	// ErrNumberPrecision is returned for numbers with more significant digits than an Oracle NUMBER can hold (38).
+	ErrNumberPrecision = errors.New("too many significant digits")
+)
+
+// numberParts is the decimal representation of a Number: (-1)^neg * 0.digits * 10^exp,
+// or an overflow marker (~ or -~) if inf.
+type numberParts struct {
+	neg, inf bool
+	// digits has no leading or trailing zeros; empty for 0.
+	digits string
+	exp    int
+}
+
+// parts parses the Number, accepting an exponent (1e+21) and the overflow markers, too.
+func (n Number) parts() (numberParts, error) {
+	var p numberParts
+	s := strings.TrimSpace(string(n))
+	if s == "" {
+		return p, errors.New("empty number")
+	}
+	if s[0] == '-' || s[0] == '+' {
+		p.neg, s = s[0] == '-', s[1:]
+	}
+	if s == "~" {
+		p.inf = true
+		return p, nil
+	}
+	mant := s
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mant = s[:i]
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return p, errors.Errorf("%q: exponent: %w", n, err)
+		}
+		p.exp = int(e)
+	}
+	intPart, fracPart := mant, ""
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		intPart, fracPart = mant[:i], mant[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return p, errors.Errorf("%q: no digits", n)
+	}
+	for _, part := range [2]string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || '9' < part[i] {
+				return p, errors.Errorf("unknown char %c in %q", part[i], n)
+			}
+		}
+	}
+	digits := intPart + fracPart
+	p.exp += len(intPart)
+	for len(digits) != 0 && digits[0] == '0' {
+		digits = digits[1:]
+		p.exp--
+	}
+	p.digits = strings.TrimRight(digits, "0")
+	if p.digits == "" {
+		return numberParts{}, nil
+	}
+	return p, nil
+}
+
+// validate the parts against the limits of an Oracle NUMBER.
+func (p numberParts) validate() error {
+	if p.inf || p.digits == "" {
+		return nil
+	}
+	if len(p.digits) > numberMaxDigits {
+		return errors.Errorf("%se%d: %d digits: %w", p.digits, p.exp, len(p.digits), ErrNumberPrecision)
+	}
+	return p.checkRange()
+}
+
+// checkRange checks the range of the parts only, as the database may return
+// more than 38 digits (e.g. for 1/3).
+func (p numberParts) checkRange() error {
+	if p.digits != "" && (p.exp < numberMinExp || p.exp > numberMaxExp) {
+		return errors.Errorf("%se%d: %w", p.digits, p.exp, ErrNumberRange)
+	}
+	return nil
+}
+
+// String returns the canonical form: without exponent, leading or trailing zeros.
+func (p numberParts) String() string {
+	if p.inf {
+		if p.neg {
+			return "-~"
+		}
+		return "~"
+	}
+	if p.digits == "" {
+		return "0"
+	}
+	var b strings.Builder
+	b.Grow(len(p.digits) + 3)
+	if p.neg {
+		b.WriteByte('-')
+	}
+	switch {
+	case p.exp <= 0:
+		b.WriteString("0.")
+		b.WriteString(strings.Repeat("0", -p.exp))
+		b.WriteString(p.digits)
+	case p.exp >= len(p.digits):
+		b.WriteString(p.digits)
+		b.WriteString(strings.Repeat("0", p.exp-len(p.digits)))
+	default:
+		b.WriteString(p.digits[:p.exp])
+		b.WriteByte('.')
+		b.WriteString(p.digits[p.exp:])
+	}
+	return b.String()
+}
+
+// setParts validates p and sets the Number to its canonical form.
+func (n *Number) setParts(p numberParts) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	*n = Number(p.String())
+	return nil
+}
+
+// Validate checks whether the Number is a valid Oracle NUMBER:
+// at most 38 significant digits, in the range of 1e-130 <= |x| < 1e126 (or zero),
+// or one of the overflow markers (~ and -~).
+func (n Number) Validate() error {
+	p, err := n.parts()
+	if err != nil {
+		return err
+	}
+	return p.validate()
+}
+
+// Canonical returns the Number in its canonical form:
+// without exponent, sign of zero, leading or trailing zeros (1.50 is 1.5, 1e3 is 1000, -0 is 0).
+func (n Number) Canonical() (Number, error) {
+	p, err := n.parts()
+	if err != nil {
+		return n, err
+	}
+	return Number(p.String()), nil
+}
+
+// Cmp compares n and m without arithmetic, and returns
+//
+//   -1 if n <  m
+//    0 if n == m
+//   +1 if n >  m
+//
+// The overflow markers -~ and ~ are less and greater than any other number.
+func (n Number) Cmp(m Number) (int, error) {
+	p, err := n.parts()
+	if err != nil {
+		return 0, err
+	}
+	q, err := m.parts()
+	if err != nil {
+		return 0, err
+	}
+	return p.cmp(q), nil
+}
+
+func (p numberParts) sign() int {
+	switch {
+	case p.neg:
+		return -1
+	case p.inf || p.digits != "":
+		return 1
+	}
+	return 0
+}
+
+func (p numberParts) cmp(q numberParts) int {
+	ps, qs := p.sign(), q.sign()
+	if ps != qs {
+		if ps < qs {
+			return -1
+		}
+		return 1
+	}
+	if ps == 0 {
+		return 0
+	}
+	// same sign: compare the absolute values, and negate for negatives
+	var c int
+	switch {
+	case p.inf && q.inf:
+		c = 0
+	case p.inf:
+		c = 1
+	case q.inf:
+		c = -1
+	case p.exp != q.exp:
+		if c = 1; p.exp < q.exp {
+			c = -1
+		}
+	default:
+		// no trailing zeros, so the longer is greater if one is the prefix of the other
+		c = strings.Compare(p.digits, q.digits)
+	}
+	return c * ps
+}
+
+// BigInt returns the Number as a *big.Int.
+// It returns an error if the Number is not an integer.
+func (n Number) BigInt() (*big.Int, error) {
+	p, err := n.parts()
+	if err != nil {
+		return nil, err
+	}
+	if p.inf {
+		return nil, errors.Errorf("%s: %w", n, ErrNumberInfinite)
+	}
+	if err = p.checkRange(); err != nil {
+		return nil, err
+	}
+	if p.exp < len(p.digits) {
+		return nil, errors.Errorf("%s is not an integer", n)
+	}
+	i, ok := new(big.Int).SetString(p.digits+strings.Repeat("0", p.exp-len(p.digits)), 10)
+	if !ok {
+		// p.digits == ""
+		return new(big.Int), nil
+	}
+	if p.neg {
+		i.Neg(i)
+	}
+	return i, nil
+}
+
+// BigRat returns the Number as a *big.Rat, exactly.
+func (n Number) BigRat() (*big.Rat, error) {
+	p, err := n.parts()
+	if err != nil {
+		return nil, err
+	}
+	if p.inf {
+		return nil, errors.Errorf("%s: %w", n, ErrNumberInfinite)
+	}
+	if err = p.checkRange(); err != nil {
+		return nil, err
+	}
+	if p.digits == "" {
+		return new(big.Rat), nil
+	}
+	num, _ := new(big.Int).SetString(p.digits, 10)
+	if p.neg {
+		num.Neg(num)
+	}
+	// value = digits * 10^(exp - len(digits))
+	scale := p.exp - len(p.digits)
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(scale))), nil)
+	if scale >= 0 {
+		return new(big.Rat).SetInt(num.Mul(num, pow)), nil
+	}
+	return new(big.Rat).SetFrac(num, pow), nil
+}
+
+// BigFloat returns the Number as a *big.Float, with 128 bits of precision.
+// The overflow markers are returned as infinities.
+func (n Number) BigFloat() (*big.Float, error) {
+	p, err := n.parts()
+	if err != nil {
+		return nil, err
+	}
+	f := new(big.Float).SetPrec(numberFloatPrec)
+	if p.inf {
+		return f.SetInf(p.neg), nil
+	}
+	if err = p.checkRange(); err != nil {
+		return nil, err
+	}
+	if p.digits == "" {
+		return f, nil
+	}
+	s := "0." + p.digits + "e" + strconv.Itoa(p.exp)
+	if p.neg {
+		s = "-" + s
+	}
+	if _, ok := f.SetString(s); !ok {
+		return nil, errors.Errorf("%s: cannot parse as big.Float", n)
+	}
+	return f, nil
+}
+
+// SetBigInt sets the Number to the value of i (NULL if i is nil).
+// It returns an error if i does not fit into an Oracle NUMBER.
+func (n *Number) SetBigInt(i *big.Int) error {
+	if i == nil {
+		*n = ""
+		return nil
+	}
+	p, err := Number(i.String()).parts()
+	if err != nil {
+		return err
+	}
+	return n.setParts(p)
+}
+
+// SetBigRat sets the Number to the value of r (NULL if r is nil).
+// It returns an error if r cannot be represented exactly as an Oracle NUMBER
+// (its denominator has other prime factors than 2 and 5, or it has too many digits).
+func (n *Number) SetBigRat(r *big.Rat) error {
+	if r == nil {
+		*n = ""
+		return nil
+	}
+	// find the smallest k, for which denom divides 10^k
+	d := new(big.Int).Set(r.Denom())
+	var twos, fives int
+	two, five := big.NewInt(2), big.NewInt(5)
+	var q, m big.Int
+	for _, f := range []struct {
+		Factor *big.Int
+		Count  *int
+	}{{two, &twos}, {five, &fives}} {
+		for {
+			if q.DivMod(d, f.Factor, &m); m.Sign() != 0 {
+				break
+			}
+			d.Set(&q)
+			*f.Count++
+		}
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return errors.Errorf("%s is not a finite decimal fraction", r.RatString())
+	}
+	k := twos
+	if fives > k {
+		k = fives
+	}
+	// num * 10^k / denom is an integer
+	num := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(k)), nil)
+	num.Mul(num, r.Num())
+	num.Quo(num, r.Denom())
+	p, err := Number(num.String() + "e-" + strconv.Itoa(k)).parts()
+	if err != nil {
+		return err
+	}
+	return n.setParts(p)
+}
+
+// SetBigFloat sets the Number to the value of f (NULL if f is nil),
+// rounded to 38 significant digits. Infinities are set as the overflow markers (~ and -~).
+func (n *Number) SetBigFloat(f *big.Float) error {
+	if f == nil {
+		*n = ""
+		return nil
+	}
+	if f.IsInf() {
+		return n.setParts(numberParts{inf: true, neg: f.Signbit()})
+	}
+	p, err := Number(f.Text('e', -1)).parts()
+	if err != nil {
+		return err
+	}
+	if len(p.digits) > numberMaxDigits {
+		if p, err = Number(f.Text('e', numberMaxDigits-1)).parts(); err != nil {
+			return err
+		}
+	}
+	return n.setParts(p)
+}
+
+// numberOf converts the scanned value to a Number.
+func numberOf(v interface{}) (Number, error) {
+	switch x := v.(type) {
+	case Number:
+		return x, nil
+	case string:
+		return Number(x), nil
+	case []byte:
+		return Number(x), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return Number(fmt.Sprintf("%d", x)), nil
+	case float32:
+		return Number(strconv.FormatFloat(float64(x), 'g', -1, 32)), nil
+	case float64:
+		return Number(strconv.FormatFloat(x, 'g', -1, 64)), nil
+	}
+	return "", errors.Errorf("unknown type %T", v)
+}
+
+// BigInt is a *big.Int usable as a query argument (driver.Valuer) and as a Scan destination (sql.Scanner).
+// A nil Int is NULL.
+type BigInt struct{ *big.Int }
+
+// BigRat is a *big.Rat usable as a query argument (driver.Valuer) and as a Scan destination (sql.Scanner).
+// A nil Rat is NULL.
+type BigRat struct{ *big.Rat }
+
+// BigFloat is a *big.Float usable as a query argument (driver.Valuer) and as a Scan destination (sql.Scanner).
+// A nil Float is NULL.
+type BigFloat struct{ *big.Float }
+
+// Value returns the BigInt as a Number.
+func (b BigInt) Value() (driver.Value, error) {
+	if b.Int == nil {
+		return nil, nil
+	}
+	var n Number
+	err := n.SetBigInt(b.Int)
+	return n, err
+}
+
+// Scan into the BigInt from a driver.Value.
+func (b *BigInt) Scan(v interface{}) error {
+	if v == nil {
+		b.Int = nil
+		return nil
+	}
+	n, err := numberOf(v)
+	if err == nil {
+		b.Int, err = n.BigInt()
+	}
+	return err
+}
+
+// Value returns the BigRat as a Number.
+func (b BigRat) Value() (driver.Value, error) {
+	if b.Rat == nil {
+		return nil, nil
+	}
+	var n Number
+	err := n.SetBigRat(b.Rat)
+	return n, err
+}
+
+// Scan into the BigRat from a driver.Value.
+func (b *BigRat) Scan(v interface{}) error {
+	if v == nil {
+		b.Rat = nil
+		return nil
+	}
+	n, err := numberOf(v)
+	if err == nil {
+		b.Rat, err = n.BigRat()
+	}
+	return err
+}
+
+// Value returns the BigFloat as a Number.
+func (b BigFloat) Value() (driver.Value, error) {
+	if b.Float == nil {
+		return nil, nil
+	}
+	var n Number
+	err := n.SetBigFloat(b.Float)
+	return n, err
+}
+
+// Scan into the BigFloat from a driver.Value.
+func (b *BigFloat) Scan(v interface{}) error {
+	if v == nil {
+		b.Float = nil
+		return nil
+	}
+	n, err := numberOf(v)
+	if err == nil {
+		b.Float, err = n.BigFloat()
+	}
+	return err
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
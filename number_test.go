@@ -0,0 +1,206 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	errors "golang.org/x/xerrors"
+)
+
+func TestNumberCanonical(t *testing.T) {
+	for in, want := range map[Number]Number{
+		"0": "0", "-0": "0", "000.000": "0", "+1": "1",
+		"1.50": "1.5", "001.5": "1.5", ".5": "0.5", "-.05": "-0.05", "5.": "5",
+		"1e3": "1000", "1.5E-3": "0.0015", "12.34e1": "123.4", "-1e+21": "-1000000000000000000000",
+		"~": "~", "-~": "-~",
+	} {
+		got, err := in.Canonical()
+		if err != nil {
+			t.Errorf("%q: %+v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: got %q, wanted %q", in, got, want)
+		}
+	}
+	for _, in := range []Number{"", "-", ".", "1.2.3", "1e", "1x", "e5", "--1", "1,5"} {
+		if got, err := in.Canonical(); err == nil {
+			t.Errorf("%q: wanted error, got %q", in, got)
+		}
+	}
+}
+
+func TestNumberValidate(t *testing.T) {
+	for in, want := range map[Number]error{
+		"0":                                     nil,
+		"~":                                     nil,
+		"1e-130":                                nil,
+		"-1e-130":                               nil,
+		"9.9e125":                               nil,
+		"1e-131":                                ErrNumberRange,
+		"1e126":                                 ErrNumberRange,
+		"-1e126":                                ErrNumberRange,
+		Number("0." + strings.Repeat("1", 38)):  nil,
+		Number(strings.Repeat("9", 38) + "000"): nil,
+		Number("0." + strings.Repeat("1", 39)):  ErrNumberPrecision,
+		Number(strings.Repeat("1", 39)):         ErrNumberPrecision,
+	} {
+		if err := in.Validate(); !errors.Is(err, want) || (want == nil) != (err == nil) {
+			t.Errorf("%q: got %v, wanted %v", in, err, want)
+		}
+	}
+}
+
+func TestNumberCmp(t *testing.T) {
+	ordered := []Number{"-~", "-1e125", "-10", "-9.99", "-9.9", "-1", "-0.001", "0",
+		"1e-130", "0.0011", "0.01", "1", "1.0000000001", "9.9", "9.99", "10", "1e125", "~"}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got, err := a.Cmp(b); err != nil || got != want {
+				t.Errorf("%q.Cmp(%q): got %d (%v), wanted %d", a, b, got, err, want)
+			}
+		}
+	}
+	if c, err := Number("1.50").Cmp("001.5e0"); err != nil || c != 0 {
+		t.Errorf("got %d (%v), wanted 0", c, err)
+	}
+	if _, err := Number("1").Cmp("x"); err == nil {
+		t.Error("wanted error")
+	}
+}
+
+func TestNumberBig(t *testing.T) {
+	const big38 = "12345678901234567890123456789012345678"
+	i, err := Number(big38).BigInt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.String() != big38 {
+		t.Errorf("got %s, wanted %s", i, big38)
+	}
+	var n Number
+	if err = n.SetBigInt(i.Neg(i)); err != nil || n != "-"+big38 {
+		t.Errorf("got %q (%v)", n, err)
+	}
+	if err = n.SetBigInt(i.Mul(i, big.NewInt(11))); !errors.Is(err, ErrNumberPrecision) {
+		t.Errorf("got %v, wanted %v", err, ErrNumberPrecision)
+	}
+	if _, err = Number("1.5").BigInt(); err == nil {
+		t.Error("wanted error for a fraction")
+	}
+	if _, err = Number("~").BigInt(); !errors.Is(err, ErrNumberInfinite) {
+		t.Errorf("got %v, wanted %v", err, ErrNumberInfinite)
+	}
+	if i, err = Number("1.2e3").BigInt(); err != nil || i.Int64() != 1200 {
+		t.Errorf("got %v (%v), wanted 1200", i, err)
+	}
+
+	// NUMBER(38,10)
+	const dec = "1234567890123456789012345678.0123456789"
+	r, err := Number(dec).BigRat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := new(big.Rat).SetString(dec); r.Cmp(want) != 0 {
+		t.Errorf("got %s, wanted %s", r.RatString(), want.RatString())
+	}
+	if err = n.SetBigRat(r); err != nil || n != dec {
+		t.Errorf("got %q (%v), wanted %q", n, err, dec)
+	}
+	if err = n.SetBigRat(big.NewRat(-3, 8)); err != nil || n != "-0.375" {
+		t.Errorf("got %q (%v), wanted -0.375", n, err)
+	}
+	if err = n.SetBigRat(big.NewRat(1, 3)); err == nil {
+		t.Errorf("wanted error for 1/3, got %q", n)
+	}
+	if err = n.SetBigRat(big.NewRat(1, 1)); err != nil || n != "1" {
+		t.Errorf("got %q (%v), wanted 1", n, err)
+	}
+
+	f, err := Number(dec).BigFloat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.SetBigFloat(f); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := n.Cmp(dec); c != 0 {
+		t.Errorf("got %q, wanted %q", n, dec)
+	}
+	if f, err = Number("-~").BigFloat(); err != nil || !f.IsInf() || f.Sign() >= 0 {
+		t.Errorf("got %v (%v), wanted -Inf", f, err)
+	}
+	if err = n.SetBigFloat(big.NewFloat(math.Inf(1))); err != nil || n != "~" {
+		t.Errorf("got %q (%v), wanted ~", n, err)
+	}
+	if err = n.SetBigFloat(big.NewFloat(0.1)); err != nil || n != "0.1" {
+		t.Errorf("got %q (%v), wanted 0.1", n, err)
+	}
+	if err = n.SetBigFloat(new(big.Float).SetPrec(256).Quo(big.NewFloat(1), big.NewFloat(3))); err != nil || n != Number("0."+strings.Repeat("3", 38)) {
+		t.Errorf("got %q (%v), wanted 38 digits", n, err)
+	}
+	if _, err = Number("1e1000000000").BigRat(); !errors.Is(err, ErrNumberRange) {
+		t.Errorf("got %v, wanted %v", err, ErrNumberRange)
+	}
+}
+
+func TestNumberBigScan(t *testing.T) {
+	var bi BigInt
+	for _, v := range []interface{}{"12", Number("12"), []byte("12"), int64(12), float64(12)} {
+		if err := bi.Scan(v); err != nil || bi.Int64() != 12 {
+			t.Errorf("%T: got %v (%v), wanted 12", v, bi.Int, err)
+		}
+	}
+	if err := bi.Scan(nil); err != nil || bi.Int != nil {
+		t.Errorf("got %v (%v), wanted nil", bi.Int, err)
+	}
+	if v, err := bi.Value(); err != nil || v != nil {
+		t.Errorf("got %v (%v), wanted nil", v, err)
+	}
+	bi.Int = big.NewInt(-5)
+	if v, err := bi.Value(); err != nil || v != Number("-5") {
+		t.Errorf("got %#v (%v), wanted -5", v, err)
+	}
+
+	var br BigRat
+	if err := br.Scan(Number("-0.25")); err != nil || br.Cmp(big.NewRat(-1, 4)) != 0 {
+		t.Errorf("got %v (%v), wanted -1/4", br.Rat, err)
+	}
+	if v, err := br.Value(); err != nil || v != Number("-0.25") {
+		t.Errorf("got %#v (%v), wanted -0.25", v, err)
+	}
+
+	var bf BigFloat
+	if err := bf.Scan(float32(0.5)); err != nil || bf.Cmp(big.NewFloat(0.5)) != 0 {
+		t.Errorf("got %v (%v), wanted 0.5", bf.Float, err)
+	}
+	if v, err := bf.Value(); err != nil || v != Number("0.5") {
+		t.Errorf("got %#v (%v), wanted 0.5", v, err)
+	}
+	if err := bf.Scan(struct{}{}); err == nil {
+		t.Error("wanted error for unknown type")
+	}
+}
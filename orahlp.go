@@ -284,6 +284,8 @@ type Conn interface {
 	Commit() error
 	Rollback() error
 	ServerVersion() (VersionInfo, error)
+	EncodingInfo() (EncodingInfo, error)
+	SodaDB() (*SodaDB, error)
 	GetObjectType(name string) (ObjectType, error)
 	NewSubscription(string, func(Event)) (*Subscription, error)
 	Startup(StartupMode) error
@@ -312,3 +314,58 @@ func getConn(ctx context.Context, ex Execer) (*conn, error) {
 func WrapRows(ctx context.Context, q Querier, rset driver.Rows) (*sql.Rows, error) {
 	return q.QueryContext(ctx, wrapResultset, rset)
 }
+
+// QueryScrollable executes the query with a scrollable cursor on the connection,
+// and calls f with the returned rows, which are closed when f returns.
+//
+// Each Scroller method positions the cursor, so the next call to Next returns the wanted row.
+// Options (such as FetchRowCount) can be given among the args, as for QueryContext.
+func QueryScrollable(ctx context.Context, sqlConn *sql.Conn, qry string, f func(Scroller) error, args ...interface{}) error {
+	c, err := getConn(ctx, sqlConn)
+	if err != nil {
+		return err
+	}
+	ds, err := c.PrepareContext(ctx, qry)
+	if err != nil {
+		return err
+	}
+	st := ds.(*statement)
+	defer st.Close()
+	Scrollable()(&st.stmtOptions)
+
+	nvs := make([]driver.NamedValue, 0, len(args))
+	for _, a := range args {
+		nv := driver.NamedValue{Ordinal: len(nvs) + 1, Value: a}
+		if na, ok := a.(sql.NamedArg); ok {
+			nv.Name, nv.Value = na.Name, na.Value
+		}
+		if err = st.CheckNamedValue(&nv); err == driver.ErrRemoveArgument {
+			continue
+		} else if err != nil {
+			return err
+		}
+		nvs = append(nvs, nv)
+	}
+	dr, err := st.QueryContext(ctx, nvs)
+	if err != nil {
+		return err
+	}
+	r := dr.(*rows)
+	err = f(r)
+	if closeErr := r.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// AsCursor returns the Cursor of the driver.Rows returned by this driver
+// (such as a SYS_REFCURSOR OUT parameter).
+//
+// A driver.Rows of this driver can be bound as is, too.
+func AsCursor(dr driver.Rows) (Cursor, error) {
+	r, ok := dr.(*rows)
+	if !ok {
+		return Cursor{}, errors.Errorf("%T is not a cursor of this driver", dr)
+	}
+	return Cursor{rows: r}, nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+/*
+#include "dpiImpl.h"
+*/
+import "C"
+import (
+	"database/sql/driver"
+	"reflect"
+	"runtime"
+	"strings"
+	"unsafe"
+
+	errors "golang.org/x/xerrors"
+)
+
+// Rowid is a ROWID, with its string (extended, base-64) form.
+//
+// ROWID OUT parameters, and ROWID columns with the DecodeTypes(Rowid{}) option, are returned as Rowid,
+// holding the ROWID handle fetched from the database, and such a Rowid argument is bound as ROWID.
+// A Rowid made from text (with NewRowid, RowidParts.Rowid or by Scan) is bound as a string,
+// which is converted to ROWID by the database, as ODPI-C can bind only the fetched handles.
+type Rowid struct {
+	s string
+	// handle is the fetched ROWID, nil for a Rowid made from text.
+	handle *rowidHandle
+}
+
+// NewRowid returns the Rowid of the string form.
+func NewRowid(s string) Rowid { return Rowid{s: s} }
+
+var rowidType = reflect.TypeOf(Rowid{})
+
+// decodesRowids reports whether the ROWID columns are returned as Rowid, with the handle (see DecodeTypes).
+func decodesRowids(goTypes []reflect.Type) bool {
+	for _, t := range goTypes {
+		if t == rowidType {
+			return true
+		}
+	}
+	return false
+}
+
+// rowidHandle holds a reference to a fetched ROWID, released when the handle is garbage collected,
+// as the Rowid values are copied freely.
+type rowidHandle struct {
+	dpiRowid *C.dpiRowid
+}
+
+// newRowidHandle returns the handle of the fetched ROWID, with a reference of its own.
+func newRowidHandle(rowid *C.dpiRowid) *rowidHandle {
+	if rowid == nil || C.dpiRowid_addRef(rowid) == C.DPI_FAILURE {
+		return nil
+	}
+	h := &rowidHandle{dpiRowid: rowid}
+	runtime.SetFinalizer(h, func(h *rowidHandle) { C.dpiRowid_release(h.dpiRowid) })
+	return h
+}
+
+// RowidParts is the decoded form of an extended ROWID.
+type RowidParts struct {
+	// Object is the data object number.
+	Object uint32
+	// File is the relative file number.
+	File uint16
+	// Block is the block number in the file.
+	Block uint32
+	// Row is the row number in the block.
+	Row uint16
+}
+
+const rowidAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// Decode the extended ROWID (OOOOOOFFFBBBBBBRRR) into its parts.
+//
+// Restricted ROWIDs and UROWIDs cannot be decoded.
+func (r Rowid) Decode() (RowidParts, error) {
+	var p RowidParts
+	s := r.s
+	if len(s) != 18 {
+		return p, errors.Errorf("%q is not an extended rowid (length %d)", s, len(s))
+	}
+	var parts [4]uint64
+	var off int
+	for i, w := range [4]int{6, 3, 6, 3} {
+		for _, c := range []byte(s[off : off+w]) {
+			n := strings.IndexByte(rowidAlphabet, c)
+			if n < 0 {
+				return p, errors.Errorf("%q: invalid character %q", s, c)
+			}
+			parts[i] = parts[i]<<6 | uint64(n)
+		}
+		off += w
+	}
+	if parts[0] > 1<<32-1 || parts[1] > 1<<16-1 || parts[2] > 1<<32-1 || parts[3] > 1<<16-1 {
+		return p, errors.Errorf("%q: part is out of range (%v)", s, parts)
+	}
+	p.Object, p.File, p.Block, p.Row = uint32(parts[0]), uint16(parts[1]), uint32(parts[2]), uint16(parts[3])
+	return p, nil
+}
+
+// Rowid encodes the parts into an extended ROWID.
+func (p RowidParts) Rowid() Rowid {
+	var b strings.Builder
+	b.Grow(18)
+	for _, part := range []struct {
+		Value uint64
+		Width uint
+	}{
+		{uint64(p.Object), 6}, {uint64(p.File), 3}, {uint64(p.Block), 6}, {uint64(p.Row), 3},
+	} {
+		for i := part.Width; i > 0; i-- {
+			b.WriteByte(rowidAlphabet[(part.Value>>(6*(i-1)))&63])
+		}
+	}
+	return Rowid{s: b.String()}
+}
+
+// String returns the ROWID as string.
+func (r Rowid) String() string { return r.s }
+
+// Value returns the ROWID as string, or nil for the zero Rowid, for driver.Valuer.
+func (r Rowid) Value() (driver.Value, error) {
+	if r.s == "" {
+		return nil, nil
+	}
+	return r.s, nil
+}
+
+// Scan the ROWID column (returned as string, or as Rowid with DecodeTypes) into r, for sql.Scanner.
+func (r *Rowid) Scan(src interface{}) error {
+	switch x := src.(type) {
+	case nil:
+		*r = Rowid{}
+	case Rowid:
+		*r = x
+	case string:
+		*r = NewRowid(x)
+	case []byte:
+		*r = NewRowid(string(x))
+	default:
+		return errors.Errorf("cannot scan %T into Rowid", src)
+	}
+	return nil
+}
+
+// hasRowidHandles reports whether the Rowid (or all the elements of the []Rowid) has been fetched,
+// so it can be bound as ROWID.
+func hasRowidHandles(v interface{}) bool {
+	switch x := v.(type) {
+	case Rowid:
+		return x.handle != nil
+	case []Rowid:
+		for _, r := range x {
+			if r.handle == nil {
+				return false
+			}
+		}
+		return len(x) != 0
+	}
+	return false
+}
+
+// dataGetRowidC returns the Rowid of the fetched ROWID, keeping its handle iff keepHandle.
+func (c *conn) dataGetRowidC(d *C.dpiData, keepHandle bool) (Rowid, error) {
+	// dpiData.value is a union, here asRowid
+	rowid := *(**C.dpiRowid)(unsafe.Pointer(&d.value))
+	var p *C.char
+	var n C.uint32_t
+	if C.dpiRowid_getStringValue(rowid, &p, &n) == C.DPI_FAILURE {
+		return Rowid{}, errors.Errorf("getStringValue: %w", c.getError())
+	}
+	r := Rowid{s: C.GoStringN(p, C.int(n))}
+	if keepHandle {
+		r.handle = newRowidHandle(rowid)
+	}
+	return r, nil
+}
+
+func (c *conn) dataGetRowid(v interface{}, data []C.dpiData) error {
+	switch x := v.(type) {
+	case *Rowid:
+		if len(data) == 0 || data[0].isNull == 1 {
+			*x = Rowid{}
+			return nil
+		}
+		var err error
+		*x, err = c.dataGetRowidC(&data[0], true)
+		return err
+	case *[]Rowid:
+		*x = (*x)[:0]
+		for i := range data {
+			var r Rowid
+			if data[i].isNull == 0 {
+				var err error
+				if r, err = c.dataGetRowidC(&data[i], true); err != nil {
+					return err
+				}
+			}
+			*x = append(*x, r)
+		}
+		return nil
+	}
+	return errors.Errorf("awaited *Rowid or *[]Rowid, got %T", v)
+}
+
+// dataSetRowid binds the fetched ROWIDs, see hasRowidHandles.
+func (c *conn) dataSetRowid(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
+	rowids := []Rowid{{}}
+	switch x := vv.(type) {
+	case Rowid:
+		rowids[0] = x
+	case []Rowid:
+		rowids = x
+	default:
+		return errors.Errorf("awaited Rowid or []Rowid, got %T", vv)
+	}
+	for i, r := range rowids {
+		if r.handle == nil {
+			data[i].isNull = 1
+			continue
+		}
+		data[i].isNull = 0
+		if C.dpiVar_setFromRowid(dv, C.uint32_t(i), r.handle.dpiRowid) == C.DPI_FAILURE {
+			return errors.Errorf("setFromRowid[%d]: %w", i, c.getError())
+		}
+		runtime.KeepAlive(r.handle)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowidDecode(t *testing.T) {
+	for _, tc := range []struct {
+		Rowid string
+		Parts RowidParts
+	}{
+		{Rowid: "AAAAABAAEAAAACXAAC", Parts: RowidParts{Object: 1, File: 4, Block: 151, Row: 2}},
+		{Rowid: "AAASdqAAHAAAAFrAAA", Parts: RowidParts{Object: 75626, File: 7, Block: 363, Row: 0}},
+	} {
+		got, err := NewRowid(tc.Rowid).Decode()
+		if err != nil {
+			t.Errorf("%q: %+v", tc.Rowid, err)
+			continue
+		}
+		if got != tc.Parts {
+			t.Errorf("%q: got %+v, wanted %+v", tc.Rowid, got, tc.Parts)
+		}
+		if back := got.Rowid(); back.String() != tc.Rowid || back.handle != nil {
+			t.Errorf("%+v: got %q, wanted %q", got, back, tc.Rowid)
+		}
+	}
+
+	max := RowidParts{Object: 1<<32 - 1, File: 1<<16 - 1, Block: 1<<32 - 1, Row: 1<<16 - 1}
+	if got, err := max.Rowid().Decode(); err != nil || got != max {
+		t.Errorf("got %+v (%+v), wanted %+v", got, err, max)
+	}
+	for _, s := range []string{"*BAMAAJwCwQL+", "AAASdqAAHAAAAFr-AA", "AAASdq///AAAAFrAAA"} {
+		if p, err := NewRowid(s).Decode(); err == nil {
+			t.Errorf("%q: wanted error, got %+v", s, p)
+		}
+	}
+}
+
+func TestRowidHandles(t *testing.T) {
+	fetched := Rowid{s: "AAAAABAAEAAAACXAAC", handle: &rowidHandle{}}
+	text := NewRowid("AAAAABAAEAAAACXAAC")
+	for _, tc := range []struct {
+		Value interface{}
+		Want  bool
+	}{
+		{Value: fetched, Want: true},
+		{Value: text},
+		{Value: []Rowid{fetched, fetched}, Want: true},
+		{Value: []Rowid{fetched, text}},
+		{Value: []Rowid{}},
+		{Value: "AAAAABAAEAAAACXAAC"},
+	} {
+		if got := hasRowidHandles(tc.Value); got != tc.Want {
+			t.Errorf("%#v: got %t, wanted %t", tc.Value, got, tc.Want)
+		}
+	}
+}
+
+func TestRowidScanValue(t *testing.T) {
+	const s = "AAAAABAAEAAAACXAAC"
+	fetched := Rowid{s: s, handle: &rowidHandle{}}
+	for _, src := range []interface{}{s, []byte(s), fetched} {
+		var r Rowid
+		if err := r.Scan(src); err != nil {
+			t.Fatalf("%T: %+v", src, err)
+		}
+		if r.String() != s {
+			t.Errorf("%T: got %q, wanted %q", src, r, s)
+		}
+		if _, wantHandle := src.(Rowid); (r.handle != nil) != wantHandle {
+			t.Errorf("%T: got handle %v", src, r.handle)
+		}
+		if v, err := r.Value(); err != nil || v != s {
+			t.Errorf("%T: got %#v (%+v), wanted %q", src, v, err, s)
+		}
+	}
+	var r Rowid
+	if err := r.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := r.Value(); err != nil || v != nil {
+		t.Errorf("got %#v (%+v), wanted nil", v, err)
+	}
+	if err := r.Scan(1); err == nil {
+		t.Error("wanted error for int")
+	}
+}
+
+func TestDecodesRowids(t *testing.T) {
+	if decodesRowids(nil) {
+		t.Error("got true for no types")
+	}
+	if !decodesRowids([]reflect.Type{reflect.TypeOf(""), reflect.TypeOf(Rowid{})}) {
+		t.Error("got false for Rowid")
+	}
+}
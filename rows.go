@@ -53,8 +53,17 @@ type rows struct {
 	bufferRowIndex C.uint32_t
 	fetched        C.uint32_t
 	finished       bool
+	// nested cursors (CURSOR(...) columns) of the current row
+	nested []*rows
+	// decoders of the columns with registered types (see DecodeTypes), nil if none
+	decoders []*typeConv
+	// rowids is true iff the ROWID columns are returned as Rowid (see DecodeTypes)
+	rowids bool
 }
 
+// errNestedClosed is returned by a nested cursor after its parent has advanced.
+var errNestedClosed = errors.New("nested cursor is closed, as its parent has advanced")
+
 // Columns returns the names of the columns. The number of
 // columns of the result is inferred from the length of the
 // slice. If a particular column name isn't known, an empty
@@ -72,6 +81,7 @@ func (r *rows) Close() error {
 	if r == nil {
 		return nil
 	}
+	r.closeNested()
 	r.columns = nil
 	r.data = nil
 	for _, v := range r.vars {
@@ -108,6 +118,9 @@ func (r *rows) Close() error {
 // int           (0, false)
 // bytea(30)     (30, true)
 func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if r.columns[index].isXML {
+		return math.MaxInt64, true
+	}
 	switch col := r.columns[index]; col.OracleType {
 	case C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_ORACLE_TYPE_NVARCHAR,
 		C.DPI_ORACLE_TYPE_CHAR, C.DPI_ORACLE_TYPE_NCHAR,
@@ -128,6 +141,9 @@ func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
 // Type names should be uppercase.
 // Examples of returned types: "VARCHAR", "NVARCHAR", "VARCHAR2", "CHAR", "TEXT", "DECIMAL", "SMALLINT", "INT", "BIGINT", "BOOL", "[]BIGINT", "JSONB", "XML", "TIMESTAMP".
 func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if r.columns[index].isXML {
+		return "XMLTYPE"
+	}
 	switch r.columns[index].OracleType {
 	case C.DPI_ORACLE_TYPE_VARCHAR:
 		return "VARCHAR2"
@@ -216,25 +232,39 @@ func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok b
 // ColumnTypeScanType returns the value type that can be used to scan types into.
 // For example, the database column type "bigint" this should return "reflect.TypeOf(int64(0))".
 func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if r.decoders != nil && r.decoders[index] != nil {
+		return r.decoders[index].goType
+	}
+	if r.columns[index].isXML {
+		return reflect.TypeOf(XML(""))
+	}
 	switch col := r.columns[index]; col.OracleType {
 	case C.DPI_NATIVE_TYPE_BYTES, C.DPI_ORACLE_TYPE_RAW,
-		C.DPI_ORACLE_TYPE_ROWID, C.DPI_NATIVE_TYPE_ROWID,
 		C.DPI_ORACLE_TYPE_LONG_RAW:
 		return reflect.TypeOf([]byte(nil))
+	case C.DPI_ORACLE_TYPE_ROWID, C.DPI_NATIVE_TYPE_ROWID:
+		if r.rowids {
+			return rowidType
+		}
+		return reflect.TypeOf("")
 	case C.DPI_ORACLE_TYPE_NUMBER:
 		switch col.NativeType {
 		case C.DPI_NATIVE_TYPE_INT64:
 			return reflect.TypeOf(int64(0))
 		case C.DPI_NATIVE_TYPE_UINT64:
 			return reflect.TypeOf(uint64(0))
-		//case C.DPI_NATIVE_TYPE_FLOAT:
-		//	return reflect.TypeOf(float32(0))
-		//case C.DPI_NATIVE_TYPE_DOUBLE:
-		//		return reflect.TypeOf(float64(0))
+		case C.DPI_NATIVE_TYPE_DOUBLE:
+			if r.NativeNumbers() {
+				return reflect.TypeOf(float64(0))
+			}
+			return reflect.TypeOf(Number(""))
 		default:
 			return reflect.TypeOf(Number(""))
 		}
 	case C.DPI_ORACLE_TYPE_NATIVE_FLOAT, C.DPI_NATIVE_TYPE_FLOAT:
+		if r.NativeNumbers() {
+			return reflect.TypeOf(float64(0))
+		}
 		return reflect.TypeOf(float32(0))
 	case C.DPI_ORACLE_TYPE_NATIVE_DOUBLE, C.DPI_NATIVE_TYPE_DOUBLE:
 		return reflect.TypeOf(float64(0))
@@ -272,8 +302,13 @@ func (r *rows) Next(dest []driver.Value) error {
 	if r.err != nil {
 		return r.err
 	}
+	// the nested cursors of the previous row are invalidated by advancing
+	r.closeNested()
 	if r.finished {
-		_ = r.Close()
+		// a scrollable cursor can go back, so it is closed only by Close
+		if !r.isScrollable {
+			_ = r.Close()
+		}
 		return io.EOF
 	}
 	if len(dest) != len(r.columns) {
@@ -289,7 +324,9 @@ func (r *rows) Next(dest []driver.Value) error {
 		}
 		if r.fetched == 0 {
 			r.finished = moreRows == 0
-			_ = r.Close()
+			if !r.isScrollable {
+				_ = r.Close()
+			}
 			return io.EOF
 		}
 		if r.data == nil {
@@ -318,6 +355,10 @@ func (r *rows) Next(dest []driver.Value) error {
 		if false && Log != nil {
 			Log("msg", "Next", "i", i, "row", r.bufferRowIndex, "typ", typ, "null", isNull) //, "data", fmt.Sprintf("%+v", d), "typ", typ)
 		}
+		if isNull && r.NullAsNil() {
+			dest[i] = nil
+			continue
+		}
 
 		switch typ {
 		case C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_ORACLE_TYPE_NVARCHAR,
@@ -325,10 +366,18 @@ func (r *rows) Next(dest []driver.Value) error {
 			C.DPI_ORACLE_TYPE_LONG_VARCHAR:
 			//fmt.Printf("CHAR\n")
 			if isNull {
-				dest[i] = ""
+				if col.isXML {
+					dest[i] = XML("")
+				} else {
+					dest[i] = ""
+				}
 				continue
 			}
 			b := C.dpiData_getBytes(d)
+			if col.isXML {
+				dest[i] = XML(C.GoStringN(b.ptr, C.int(b.length)))
+				break
+			}
 			if b.length == 0 {
 				dest[i] = ""
 				continue
@@ -350,7 +399,10 @@ func (r *rows) Next(dest []driver.Value) error {
 				//dest[i] = float32(C.dpiData_getFloat(d))
 				dest[i] = printFloat(float64(C.dpiData_getFloat(d)))
 			case C.DPI_NATIVE_TYPE_DOUBLE:
-				//dest[i] = float64(C.dpiData_getDouble(d))
+				if r.NativeNumbers() {
+					dest[i] = float64(C.dpiData_getDouble(d))
+					break
+				}
 				dest[i] = printFloat(float64(C.dpiData_getDouble(d)))
 			default:
 				b := C.dpiData_getBytes(d)
@@ -368,8 +420,23 @@ func (r *rows) Next(dest []driver.Value) error {
 				Log("msg", "num", "t", col.NativeType, "i", i, "dest", fmt.Sprintf("%T %+v", dest[i], dest[i]))
 			}
 
-		case C.DPI_ORACLE_TYPE_ROWID, C.DPI_NATIVE_TYPE_ROWID,
-			C.DPI_ORACLE_TYPE_RAW, C.DPI_ORACLE_TYPE_LONG_RAW:
+		case C.DPI_ORACLE_TYPE_ROWID, C.DPI_NATIVE_TYPE_ROWID:
+			if isNull {
+				dest[i] = nil
+				continue
+			}
+			rowid, err := r.dataGetRowidC(d, r.rowids)
+			if err != nil {
+				return err
+			}
+			if r.rowids {
+				dest[i] = rowid
+			} else {
+				// as string, to be scanned into *string and *[]byte, too
+				dest[i] = rowid.s
+			}
+
+		case C.DPI_ORACLE_TYPE_RAW, C.DPI_ORACLE_TYPE_LONG_RAW:
 			if isNull {
 				dest[i] = nil
 				continue
@@ -385,6 +452,10 @@ func (r *rows) Next(dest []driver.Value) error {
 				dest[i] = nil
 				continue
 			}
+			if r.NativeNumbers() {
+				dest[i] = float64(C.dpiData_getFloat(d))
+				break
+			}
 			dest[i] = float32(C.dpiData_getFloat(d))
 		case C.DPI_ORACLE_TYPE_NATIVE_DOUBLE, C.DPI_NATIVE_TYPE_DOUBLE:
 			if isNull {
@@ -412,8 +483,17 @@ func (r *rows) Next(dest []driver.Value) error {
 				dest[i] = time.Time{}
 				continue
 			}
+			if col.NativeType == C.DPI_NATIVE_TYPE_BYTES {
+				// TIMESTAMP WITH TIME ZONE with region name, see TimeZoneRegions
+				b := C.dpiData_getBytes(d)
+				var err error
+				if dest[i], err = parseTZRegion(C.GoStringN(b.ptr, C.int(b.length))); err != nil {
+					return errors.Errorf("%s: %w", col.Name, err)
+				}
+				break
+			}
 			ts := C.dpiData_getTimestamp(d)
-			tz := r.conn.timeZone
+			tz := r.statement.location()
 			if col.OracleType != C.DPI_ORACLE_TYPE_TIMESTAMP && col.OracleType != C.DPI_ORACLE_TYPE_DATE {
 				tz = timeZoneFor(ts.tzHourOffset, ts.tzMinuteOffset)
 			}
@@ -423,12 +503,7 @@ func (r *rows) Next(dest []driver.Value) error {
 				dest[i] = nil
 				continue
 			}
-			ds := C.dpiData_getIntervalDS(d)
-			dest[i] = time.Duration(ds.days)*24*time.Hour +
-				time.Duration(ds.hours)*time.Hour +
-				time.Duration(ds.minutes)*time.Minute +
-				time.Duration(ds.seconds)*time.Second +
-				time.Duration(ds.fseconds)
+			dest[i] = getIntervalDS(d)
 		case C.DPI_ORACLE_TYPE_INTERVAL_YM, C.DPI_NATIVE_TYPE_INTERVAL_YM:
 			if isNull {
 				dest[i] = nil
@@ -461,7 +536,7 @@ func (r *rows) Next(dest []driver.Value) error {
 				stringBuilders.Put(sb)
 				continue
 			}
-			dest[i] = &Lob{Reader: rdr, IsClob: rdr.IsClob}
+			dest[i] = &Lob{Reader: rdr, IsClob: rdr.IsClob, IsNClob: typ == C.DPI_ORACLE_TYPE_NCLOB}
 
 		case C.DPI_ORACLE_TYPE_STMT, C.DPI_NATIVE_TYPE_STMT:
 			if isNull {
@@ -470,6 +545,7 @@ func (r *rows) Next(dest []driver.Value) error {
 			}
 			st := &statement{conn: r.conn, dpiStmt: C.dpiData_getStmt(d),
 				stmtOptions: r.statement.stmtOptions, // inherit parent statement's options
+				tzOverride:  r.statement.tzOverride,
 			}
 			var colCount C.uint32_t
 			if C.dpiStmt_getNumQueryColumns(st.dpiStmt, &colCount) == C.DPI_FAILURE {
@@ -481,6 +557,7 @@ func (r *rows) Next(dest []driver.Value) error {
 			if err != nil {
 				return err
 			}
+			r.nested = append(r.nested, r2)
 			dest[i] = r2
 
 		case C.DPI_ORACLE_TYPE_BOOLEAN, C.DPI_NATIVE_TYPE_BOOLEAN:
@@ -505,6 +582,13 @@ func (r *rows) Next(dest []driver.Value) error {
 			return errors.Errorf("unsupported column type %d", typ)
 		}
 
+		if r.decoders != nil && r.decoders[i] != nil && !isNull {
+			v, err := r.decoders[i].decodeValue(dest[i])
+			if err != nil {
+				return errors.Errorf("%s: %w", col.Name, err)
+			}
+			dest[i] = v.Interface()
+		}
 		//fmt.Printf("dest[%d]=%#v\n", i, dest[i])
 	}
 	r.bufferRowIndex++
@@ -517,6 +601,70 @@ func (r *rows) Next(dest []driver.Value) error {
 	return nil
 }
 
+// Cursor is an open cursor of a connection,
+// to be bound as an IN SYS_REFCURSOR parameter on the same connection.
+//
+// Get it with AsCursor. The cursor is still owned (and must be closed) by the rows it came from.
+type Cursor struct {
+	rows *rows
+}
+
+// closeNested closes the nested cursors of the current row.
+// They are valid only till the parent advances, as the parent's next fetch
+// reuses the statement handles in its buffer.
+func (r *rows) closeNested() {
+	for _, n := range r.nested {
+		if n.err == nil && n.statement != nil {
+			n.err = errNestedClosed
+		}
+		_ = n.Close()
+	}
+	r.nested = r.nested[:0]
+}
+
+// Scroller is the driver.Rows extension for scrollable cursors (see the Scrollable option).
+//
+// Each method positions the cursor, so the next call to Next returns the wanted row.
+// Row numbers start from 1. A scrollable cursor stays open after Next returned io.EOF,
+// so it can go back till it is closed.
+type Scroller interface {
+	driver.Rows
+	// First positions to the first row.
+	First() error
+	// Last positions to the last row.
+	Last() error
+	// Prior positions to the row before the last returned one.
+	Prior() error
+	// Absolute positions to the given row.
+	Absolute(row int) error
+	// Relative positions to the given offset from the last returned row.
+	Relative(offset int) error
+}
+
+var _ = Scroller((*rows)(nil))
+
+func (r *rows) First() error              { return r.scroll(C.DPI_MODE_FETCH_FIRST, 0) }
+func (r *rows) Last() error               { return r.scroll(C.DPI_MODE_FETCH_LAST, 0) }
+func (r *rows) Prior() error              { return r.scroll(C.DPI_MODE_FETCH_PRIOR, 0) }
+func (r *rows) Absolute(row int) error    { return r.scroll(C.DPI_MODE_FETCH_ABSOLUTE, row) }
+func (r *rows) Relative(offset int) error { return r.scroll(C.DPI_MODE_FETCH_RELATIVE, offset) }
+
+func (r *rows) scroll(mode C.dpiFetchMode, offset int) error {
+	if r.statement == nil || r.dpiStmt == nil {
+		return errors.New("scroll: rows are closed")
+	}
+	if !r.isScrollable {
+		return errors.New("scroll: not a scrollable cursor (use the Scrollable option)")
+	}
+	r.closeNested()
+	// the not yet returned rows of the buffer has been already counted as fetched by ODPI-C.
+	if C.dpiStmt_scroll(r.dpiStmt, mode, C.int32_t(offset), -C.int32_t(r.fetched)) == C.DPI_FAILURE {
+		return errors.Errorf("scroll: %w", r.getError())
+	}
+	r.fetched, r.finished = 0, false
+	return nil
+}
+
 var _ = driver.Rows((*directRow)(nil))
 
 type directRow struct {
@@ -600,7 +748,7 @@ func (r *rows) NextResultSet() error {
 			return errors.Errorf("getImplicitResult: %w", io.EOF)
 		}
 	}
-	st := &statement{conn: r.conn, dpiStmt: r.nextRs}
+	st := &statement{conn: r.conn, dpiStmt: r.nextRs, stmtOptions: r.statement.stmtOptions, tzOverride: r.statement.tzOverride}
 
 	var n C.uint32_t
 	if C.dpiStmt_getNumQueryColumns(st.dpiStmt, &n) == C.DPI_FAILURE {
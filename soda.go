@@ -0,0 +1,542 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+/*
+#include <stdlib.h>
+#include "dpiImpl.h"
+*/
+import "C"
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"unsafe"
+
+	errors "golang.org/x/xerrors"
+)
+
+// ErrSodaNoCollection is returned by OpenCollection for a non-existing collection.
+var ErrSodaNoCollection = errors.New("no such SODA collection")
+
+// SodaDB is the Simple Oracle Document Access interface of a connection.
+// It needs Oracle Client 18.3 and Oracle Database 18.1 or later.
+//
+// The operations are committed immediately, unless the connection is in a transaction.
+//
+// WARNING: the connection must not be closed before the SodaDB and its collections are closed!
+// So use an sql.Conn for it.
+type SodaDB struct {
+	conn      *conn
+	dpiSodaDb *C.dpiSodaDb
+}
+
+// SodaDocument is a document of a SODA collection.
+//
+// For inserting and replacing, only Key (for collections with client assigned keys),
+// Content and MediaType (for non-JSON documents) are used.
+type SodaDocument struct {
+	Key, Version, MediaType string
+	// CreatedOn and LastModified are timestamps in ISO 8601 format.
+	CreatedOn, LastModified string
+	Content                 []byte
+}
+
+// Unmarshal the JSON content of the document into v.
+func (doc SodaDocument) Unmarshal(v interface{}) error {
+	return json.Unmarshal(doc.Content, v)
+}
+
+// SodaOperOptions selects the documents of the find, count, replace and remove operations.
+type SodaOperOptions struct {
+	// Keys and Key select the documents by key.
+	Keys []string
+	Key  string
+	// Version selects the document with the given version (for optimistic locking).
+	Version string
+	// Filter is a query-by-example (QBE) filter specification, such as {"name": "Alice"}.
+	Filter string
+	// Skip the first Skip documents, and return at most Limit documents (if not zero).
+	Skip, Limit uint32
+}
+
+// SodaDB returns the SODA interface of the connection.
+func (c *conn) SodaDB() (*SodaDB, error) {
+	c.RLock()
+	defer c.RUnlock()
+	db := SodaDB{conn: c}
+	if C.dpiConn_getSodaDb(c.dpiConn, &db.dpiSodaDb) == C.DPI_FAILURE {
+		return nil, errors.Errorf("getSodaDb: %w", c.getError())
+	}
+	return &db, nil
+}
+
+// Close the SodaDB.
+func (db *SodaDB) Close() error {
+	c, d := db.conn, db.dpiSodaDb
+	db.dpiSodaDb = nil
+	if d == nil {
+		return nil
+	}
+	if C.dpiSodaDb_release(d) == C.DPI_FAILURE {
+		return errors.Errorf("release: %w", c.getError())
+	}
+	return nil
+}
+
+// flags returns the flags for a write operation.
+func (db *SodaDB) flags() C.uint32_t {
+	if db.conn.inTransaction {
+		return C.DPI_SODA_FLAGS_DEFAULT
+	}
+	return C.DPI_SODA_FLAGS_ATOMIC_COMMIT
+}
+
+// call f (a SODA operation, returning DPI_SUCCESS or DPI_FAILURE),
+// breaking it if ctx is done.
+func (db *SodaDB) call(ctx context.Context, name string, f func() C.int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c := db.conn
+	c.RLock()
+	defer c.RUnlock()
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		if f() == C.DPI_FAILURE {
+			done <- maybeBadConn(errors.Errorf("%s: %w", name, c.getError()), c)
+			return
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// the operation must finish before its arguments are freed
+		_ = c.Break()
+		if err := <-done; err == nil {
+			return nil
+		}
+		return ctx.Err()
+	}
+}
+
+// CreateCollection creates a collection with the given metadata (may be empty, for the defaults),
+// or opens it if it already exists with the same metadata.
+func (db *SodaDB) CreateCollection(ctx context.Context, name, metadata string) (*SodaCollection, error) {
+	cName, cMeta := C.CString(name), C.CString(metadata)
+	defer C.free(unsafe.Pointer(cName))
+	defer C.free(unsafe.Pointer(cMeta))
+	coll := SodaCollection{db: db, name: name}
+	if err := db.call(ctx, "createCollection "+name, func() C.int {
+		return C.dpiSodaDb_createCollection(db.dpiSodaDb, cName, C.uint32_t(len(name)),
+			cMeta, C.uint32_t(len(metadata)), db.flags(), &coll.dpiSodaColl)
+	}); err != nil {
+		return nil, err
+	}
+	return &coll, nil
+}
+
+// OpenCollection opens the existing collection, or returns ErrSodaNoCollection.
+func (db *SodaDB) OpenCollection(ctx context.Context, name string) (*SodaCollection, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	coll := SodaCollection{db: db, name: name}
+	if err := db.call(ctx, "openCollection "+name, func() C.int {
+		return C.dpiSodaDb_openCollection(db.dpiSodaDb, cName, C.uint32_t(len(name)), db.flags(), &coll.dpiSodaColl)
+	}); err != nil {
+		return nil, err
+	}
+	if coll.dpiSodaColl == nil {
+		return nil, errors.Errorf("%s: %w", name, ErrSodaNoCollection)
+	}
+	return &coll, nil
+}
+
+// CollectionNames returns the names of the collections, in order,
+// starting with startName (if not empty), at most limit (if not zero).
+func (db *SodaDB) CollectionNames(ctx context.Context, startName string, limit int) ([]string, error) {
+	var cStart *C.char
+	if startName != "" {
+		cStart = C.CString(startName)
+		defer C.free(unsafe.Pointer(cStart))
+	}
+	var names C.dpiSodaCollNames
+	if err := db.call(ctx, "getCollectionNames", func() C.int {
+		return C.dpiSodaDb_getCollectionNames(db.dpiSodaDb, cStart, C.uint32_t(len(startName)),
+			C.uint32_t(limit), C.DPI_SODA_FLAGS_DEFAULT, &names)
+	}); err != nil {
+		return nil, err
+	}
+	n := int(names.numNames)
+	result := make([]string, n)
+	if n != 0 {
+		ptrs := (*[maxArraySize]*C.char)(unsafe.Pointer(names.names))[:n:n]
+		lengths := (*[maxArraySize]C.uint32_t)(unsafe.Pointer(names.nameLengths))[:n:n]
+		for i := range result {
+			result[i] = C.GoStringN(ptrs[i], C.int(lengths[i]))
+		}
+	}
+	if C.dpiSodaDb_freeCollectionNames(db.dpiSodaDb, &names) == C.DPI_FAILURE {
+		return result, errors.Errorf("freeCollectionNames: %w", db.conn.getError())
+	}
+	return result, nil
+}
+
+// newDocument creates a dpiSodaDoc from doc.
+func (db *SodaDB) newDocument(doc SodaDocument) (*C.dpiSodaDoc, error) {
+	var cKey, cContent, cMediaType *C.char
+	if doc.Key != "" {
+		cKey = C.CString(doc.Key)
+		defer C.free(unsafe.Pointer(cKey))
+	}
+	if len(doc.Content) != 0 {
+		cContent = (*C.char)(C.CBytes(doc.Content))
+		defer C.free(unsafe.Pointer(cContent))
+	}
+	if doc.MediaType != "" {
+		cMediaType = C.CString(doc.MediaType)
+		defer C.free(unsafe.Pointer(cMediaType))
+	}
+	var d *C.dpiSodaDoc
+	if C.dpiSodaDb_createDocument(db.dpiSodaDb,
+		cKey, C.uint32_t(len(doc.Key)),
+		cContent, C.uint32_t(len(doc.Content)),
+		cMediaType, C.uint32_t(len(doc.MediaType)),
+		C.DPI_SODA_FLAGS_DEFAULT, &d,
+	) == C.DPI_FAILURE {
+		return nil, errors.Errorf("createDocument: %w", db.conn.getError())
+	}
+	return d, nil
+}
+
+// readDocument reads and releases the dpiSodaDoc.
+func (db *SodaDB) readDocument(d *C.dpiSodaDoc, withContent bool) (SodaDocument, error) {
+	defer C.dpiSodaDoc_release(d)
+	var doc SodaDocument
+	var value *C.char
+	var length C.uint32_t
+	for _, f := range []struct {
+		name string
+		get  func() C.int
+		dst  *string
+	}{
+		{"key", func() C.int { return C.dpiSodaDoc_getKey(d, &value, &length) }, &doc.Key},
+		{"version", func() C.int { return C.dpiSodaDoc_getVersion(d, &value, &length) }, &doc.Version},
+		{"mediaType", func() C.int { return C.dpiSodaDoc_getMediaType(d, &value, &length) }, &doc.MediaType},
+		{"createdOn", func() C.int { return C.dpiSodaDoc_getCreatedOn(d, &value, &length) }, &doc.CreatedOn},
+		{"lastModified", func() C.int { return C.dpiSodaDoc_getLastModified(d, &value, &length) }, &doc.LastModified},
+	} {
+		value, length = nil, 0
+		if f.get() == C.DPI_FAILURE {
+			return doc, errors.Errorf("get %s: %w", f.name, db.conn.getError())
+		}
+		if length != 0 {
+			*f.dst = C.GoStringN(value, C.int(length))
+		}
+	}
+	if !withContent {
+		return doc, nil
+	}
+	var encoding *C.char
+	value, length = nil, 0
+	if C.dpiSodaDoc_getContent(d, &value, &length, &encoding) == C.DPI_FAILURE {
+		return doc, errors.Errorf("getContent: %w", db.conn.getError())
+	}
+	if length != 0 {
+		doc.Content = C.GoBytes(unsafe.Pointer(value), C.int(length))
+	}
+	return doc, nil
+}
+
+// SodaCollection is a SODA collection.
+type SodaCollection struct {
+	db          *SodaDB
+	dpiSodaColl *C.dpiSodaColl
+	name        string
+}
+
+// Name of the collection.
+func (coll *SodaCollection) Name() string { return coll.name }
+
+// Close the collection.
+func (coll *SodaCollection) Close() error {
+	c := coll.dpiSodaColl
+	coll.dpiSodaColl = nil
+	if c == nil {
+		return nil
+	}
+	if C.dpiSodaColl_release(c) == C.DPI_FAILURE {
+		return errors.Errorf("release: %w", coll.db.conn.getError())
+	}
+	return nil
+}
+
+// Metadata returns the JSON metadata of the collection.
+func (coll *SodaCollection) Metadata() (string, error) {
+	var value *C.char
+	var length C.uint32_t
+	if C.dpiSodaColl_getMetadata(coll.dpiSodaColl, &value, &length) == C.DPI_FAILURE {
+		return "", errors.Errorf("getMetadata: %w", coll.db.conn.getError())
+	}
+	return C.GoStringN(value, C.int(length)), nil
+}
+
+// Drop the collection, returning whether it has been dropped.
+func (coll *SodaCollection) Drop(ctx context.Context) (bool, error) {
+	var isDropped C.int
+	err := coll.db.call(ctx, "drop "+coll.name, func() C.int {
+		return C.dpiSodaColl_drop(coll.dpiSodaColl, coll.db.flags(), &isDropped)
+	})
+	return isDropped == 1, err
+}
+
+// InsertOne inserts the document, and returns it without the content,
+// with the assigned key, version and timestamps.
+func (coll *SodaCollection) InsertOne(ctx context.Context, doc SodaDocument) (SodaDocument, error) {
+	d, err := coll.db.newDocument(doc)
+	if err != nil {
+		return SodaDocument{}, err
+	}
+	defer C.dpiSodaDoc_release(d)
+	var inserted *C.dpiSodaDoc
+	if err = coll.db.call(ctx, "insertOne", func() C.int {
+		return C.dpiSodaColl_insertOne(coll.dpiSodaColl, d, coll.db.flags(), &inserted)
+	}); err != nil {
+		return SodaDocument{}, err
+	}
+	return coll.db.readDocument(inserted, false)
+}
+
+// InsertMany inserts the documents (in one round-trip), and returns them
+// without the content, with the assigned keys, versions and timestamps.
+//
+// It needs Oracle Client 18.5 or later.
+func (coll *SodaCollection) InsertMany(ctx context.Context, docs []SodaDocument) ([]SodaDocument, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	n := len(docs)
+	ds := (*[maxArraySize]*C.dpiSodaDoc)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof((*C.dpiSodaDoc)(nil)))))[:n:n]
+	inserted := (*[maxArraySize]*C.dpiSodaDoc)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof((*C.dpiSodaDoc)(nil)))))[:n:n]
+	defer func() {
+		for _, d := range ds {
+			if d != nil {
+				C.dpiSodaDoc_release(d)
+			}
+		}
+		C.free(unsafe.Pointer(&ds[0]))
+		C.free(unsafe.Pointer(&inserted[0]))
+	}()
+	for i, doc := range docs {
+		var err error
+		if ds[i], err = coll.db.newDocument(doc); err != nil {
+			return nil, errors.Errorf("%d. document: %w", i, err)
+		}
+	}
+	if err := coll.db.call(ctx, "insertMany", func() C.int {
+		return C.dpiSodaColl_insertMany(coll.dpiSodaColl, C.uint32_t(n), &ds[0], coll.db.flags(), &inserted[0])
+	}); err != nil {
+		return nil, err
+	}
+	result := make([]SodaDocument, n)
+	var firstErr error
+	for i, d := range inserted {
+		if d == nil {
+			continue
+		}
+		var err error
+		if result[i], err = coll.db.readDocument(d, false); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return result, firstErr
+}
+
+// FindOne returns the first document selected by opts.
+// The bool is false if there is no such document.
+func (coll *SodaCollection) FindOne(ctx context.Context, opts SodaOperOptions) (SodaDocument, bool, error) {
+	var d *C.dpiSodaDoc
+	if err := coll.withOptions(ctx, "findOne", opts, func(o *C.dpiSodaOperOptions) C.int {
+		return C.dpiSodaColl_findOne(coll.dpiSodaColl, o, C.DPI_SODA_FLAGS_DEFAULT, &d)
+	}); err != nil || d == nil {
+		return SodaDocument{}, false, err
+	}
+	doc, err := coll.db.readDocument(d, true)
+	return doc, true, err
+}
+
+// Find returns a cursor for the documents selected by opts.
+func (coll *SodaCollection) Find(ctx context.Context, opts SodaOperOptions) (*SodaDocCursor, error) {
+	cur := SodaDocCursor{db: coll.db}
+	if err := coll.withOptions(ctx, "find", opts, func(o *C.dpiSodaOperOptions) C.int {
+		return C.dpiSodaColl_find(coll.dpiSodaColl, o, C.DPI_SODA_FLAGS_DEFAULT, &cur.dpiSodaDocCursor)
+	}); err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
+
+// Count returns the number of documents selected by opts (Skip and Limit are not allowed).
+func (coll *SodaCollection) Count(ctx context.Context, opts SodaOperOptions) (uint64, error) {
+	var count C.uint64_t
+	err := coll.withOptions(ctx, "getDocCount", opts, func(o *C.dpiSodaOperOptions) C.int {
+		return C.dpiSodaColl_getDocCount(coll.dpiSodaColl, o, C.DPI_SODA_FLAGS_DEFAULT, &count)
+	})
+	return uint64(count), err
+}
+
+// ReplaceOne replaces the content of the document selected by opts (usually by Key, and maybe Version)
+// with the content of doc. It returns whether a document has been replaced,
+// and the replaced document's new version and timestamps.
+func (coll *SodaCollection) ReplaceOne(ctx context.Context, opts SodaOperOptions, doc SodaDocument) (bool, SodaDocument, error) {
+	d, err := coll.db.newDocument(doc)
+	if err != nil {
+		return false, SodaDocument{}, err
+	}
+	defer C.dpiSodaDoc_release(d)
+	var replaced C.int
+	var replacedDoc *C.dpiSodaDoc
+	if err = coll.withOptions(ctx, "replaceOne", opts, func(o *C.dpiSodaOperOptions) C.int {
+		return C.dpiSodaColl_replaceOne(coll.dpiSodaColl, o, d, coll.db.flags(), &replaced, &replacedDoc)
+	}); err != nil || replacedDoc == nil {
+		return replaced == 1, SodaDocument{}, err
+	}
+	result, err := coll.db.readDocument(replacedDoc, false)
+	return replaced == 1, result, err
+}
+
+// Remove the documents selected by opts, and return their number.
+func (coll *SodaCollection) Remove(ctx context.Context, opts SodaOperOptions) (uint64, error) {
+	var count C.uint64_t
+	err := coll.withOptions(ctx, "remove", opts, func(o *C.dpiSodaOperOptions) C.int {
+		return C.dpiSodaColl_remove(coll.dpiSodaColl, o, coll.db.flags(), &count)
+	})
+	return uint64(count), err
+}
+
+// CreateIndex creates an index by the JSON index specification,
+// such as {"name": "NAME_IDX", "fields": [{"path": "name", "datatype": "string"}]}.
+func (coll *SodaCollection) CreateIndex(ctx context.Context, spec string) error {
+	cSpec := C.CString(spec)
+	defer C.free(unsafe.Pointer(cSpec))
+	return coll.db.call(ctx, "createIndex", func() C.int {
+		return C.dpiSodaColl_createIndex(coll.dpiSodaColl, cSpec, C.uint32_t(len(spec)), coll.db.flags())
+	})
+}
+
+// DropIndex drops the index, returning whether it has been dropped.
+// With force, a domain index is dropped even if the underlying Oracle Text index cannot be.
+func (coll *SodaCollection) DropIndex(ctx context.Context, name string, force bool) (bool, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	flags := coll.db.flags()
+	if force {
+		flags |= C.DPI_SODA_FLAGS_INDEX_DROP_FORCE
+	}
+	var isDropped C.int
+	err := coll.db.call(ctx, "dropIndex "+name, func() C.int {
+		return C.dpiSodaColl_dropIndex(coll.dpiSodaColl, cName, C.uint32_t(len(name)), flags, &isDropped)
+	})
+	return isDropped == 1, err
+}
+
+// DataGuide returns the data guide (the JSON schema summarizing the documents) of the collection,
+// which needs a JSON search index on it.
+func (coll *SodaCollection) DataGuide(ctx context.Context) (SodaDocument, error) {
+	var d *C.dpiSodaDoc
+	if err := coll.db.call(ctx, "getDataGuide", func() C.int {
+		return C.dpiSodaColl_getDataGuide(coll.dpiSodaColl, C.DPI_SODA_FLAGS_DEFAULT, &d)
+	}); err != nil || d == nil {
+		return SodaDocument{}, err
+	}
+	return coll.db.readDocument(d, true)
+}
+
+// withOptions calls f with opts converted to dpiSodaOperOptions.
+func (coll *SodaCollection) withOptions(ctx context.Context, name string, opts SodaOperOptions, f func(*C.dpiSodaOperOptions) C.int) error {
+	var o C.dpiSodaOperOptions
+	if C.dpiContext_initSodaOperOptions(coll.db.conn.dpiContext, &o) == C.DPI_FAILURE {
+		return errors.Errorf("initSodaOperOptions: %w", coll.db.conn.getError())
+	}
+	var toFree []unsafe.Pointer
+	defer func() {
+		for _, p := range toFree {
+			C.free(p)
+		}
+	}()
+	cString := func(s string) (*C.char, C.uint32_t) {
+		if s == "" {
+			return nil, 0
+		}
+		p := C.CString(s)
+		toFree = append(toFree, unsafe.Pointer(p))
+		return p, C.uint32_t(len(s))
+	}
+	if n := len(opts.Keys); n != 0 {
+		keys := (*[maxArraySize]*C.char)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof((*C.char)(nil)))))[:n:n]
+		lengths := (*[maxArraySize]C.uint32_t)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(C.uint32_t(0)))))[:n:n]
+		toFree = append(toFree, unsafe.Pointer(&keys[0]), unsafe.Pointer(&lengths[0]))
+		for i, k := range opts.Keys {
+			keys[i], lengths[i] = cString(k)
+		}
+		o.numKeys, o.keys, o.keyLengths = C.uint32_t(n), &keys[0], &lengths[0]
+	}
+	o.key, o.keyLength = cString(opts.Key)
+	o.version, o.versionLength = cString(opts.Version)
+	o.filter, o.filterLength = cString(opts.Filter)
+	o.skip, o.limit = C.uint32_t(opts.Skip), C.uint32_t(opts.Limit)
+	return coll.db.call(ctx, name, func() C.int { return f(&o) })
+}
+
+// SodaDocCursor iterates over the documents found by SodaCollection.Find.
+type SodaDocCursor struct {
+	db               *SodaDB
+	dpiSodaDocCursor *C.dpiSodaDocCursor
+}
+
+// Next returns the next document, or io.EOF.
+func (cur *SodaDocCursor) Next(ctx context.Context) (SodaDocument, error) {
+	if cur.dpiSodaDocCursor == nil {
+		return SodaDocument{}, io.EOF
+	}
+	var d *C.dpiSodaDoc
+	if err := cur.db.call(ctx, "getNext", func() C.int {
+		return C.dpiSodaDocCursor_getNext(cur.dpiSodaDocCursor, C.DPI_SODA_FLAGS_DEFAULT, &d)
+	}); err != nil {
+		return SodaDocument{}, err
+	}
+	if d == nil {
+		return SodaDocument{}, io.EOF
+	}
+	return cur.db.readDocument(d, true)
+}
+
+// Close the cursor.
+func (cur *SodaDocCursor) Close() error {
+	c := cur.dpiSodaDocCursor
+	cur.dpiSodaDocCursor = nil
+	if c == nil {
+		return nil
+	}
+	if C.dpiSodaDocCursor_release(c) == C.DPI_FAILURE {
+		return errors.Errorf("release: %w", cur.db.conn.getError())
+	}
+	return nil
+}
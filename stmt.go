@@ -23,13 +23,16 @@ const int sizeof_dpiData = sizeof(void);
 */
 import "C"
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 	"unsafe"
@@ -46,6 +49,13 @@ type stmtOptions struct {
 	lobAsReader         bool
 	magicTypeConversion bool
 	numberAsString      bool
+	batchErrors         bool
+	rowCounts           *[]int64
+	scrollable          bool
+	nullAsNil           bool
+	nativeNumbers       bool
+	tzRegions           bool
+	decodeTypes         []reflect.Type
 }
 
 func (o stmtOptions) ExecMode() C.dpiExecMode {
@@ -74,6 +84,12 @@ func (o stmtOptions) LobAsReader() bool  { return o.lobAsReader }
 
 func (o stmtOptions) MagicTypeConversion() bool { return o.magicTypeConversion }
 func (o stmtOptions) NumberAsString() bool      { return o.numberAsString }
+func (o stmtOptions) BatchErrors() bool         { return o.batchErrors }
+func (o stmtOptions) ArrayDMLRowCounts() bool   { return o.rowCounts != nil }
+func (o stmtOptions) Scrollable() bool          { return o.scrollable }
+func (o stmtOptions) NullAsNil() bool           { return o.nullAsNil }
+func (o stmtOptions) NativeNumbers() bool       { return o.nativeNumbers }
+func (o stmtOptions) TimeZoneRegions() bool     { return o.tzRegions }
 
 // Option holds statement options.
 type Option func(*stmtOptions)
@@ -142,6 +158,38 @@ func NumberAsString() Option {
 	return func(o *stmtOptions) { o.numberAsString = true }
 }
 
+// NullAsNil returns an option to return nil for all NULL columns,
+// instead of the zero value ("" for strings, time.Time{} for dates) of some types.
+//
+// This may become the default in the next major version.
+// It can be set for all statements with the nullAsNil=1 connection parameter.
+func NullAsNil() Option {
+	return func(o *stmtOptions) { o.nullAsNil = true }
+}
+
+// NativeNumbers returns an option to fetch the approximate numbers natively:
+// FLOAT(b), BINARY_FLOAT and BINARY_DOUBLE columns as float64, saving the Number string parsing.
+// (NUMBER(p,0) columns with p <= 18 are always fetched as int64.)
+//
+// The other NUMBERs (NUMBER(p,s) with s > 0 or p > 18, and NUMBER without precision) are still returned as Number.
+// It can be set for all statements with the nativeNumbers=1 connection parameter, or WithNativeNumbers.
+func NativeNumbers() Option {
+	return func(o *stmtOptions) { o.nativeNumbers = true }
+}
+
+// TimeZoneRegions returns an option to preserve the time zone region names
+// of TIMESTAMP WITH TIME ZONE values: they are fetched in the location of the region
+// (e.g. Europe/Budapest, instead of +02:00), and the time.Time arguments in a named location
+// are bound with that region.
+//
+// As ODPI-C transfers these values with the offset only, they are converted to/from text,
+// so this option sets the NLS_TIMESTAMP_TZ_FORMAT of the session (restored when the connection is closed).
+// The time.Time arguments with region are bound as text, so they can be used only
+// where a TIMESTAMP WITH TIME ZONE is expected.
+func TimeZoneRegions() Option {
+	return func(o *stmtOptions) { o.tzRegions = true }
+}
+
 // CallTimeout sets the round-trip timeout (OCI_ATTR_CALL_TIMEOUT).
 //
 // See https://docs.oracle.com/en/database/oracle/oracle-database/18/lnoci/handle-and-descriptor-attributes.html#GUID-D8EE68EB-7E38-4068-B06E-DF5686379E5E
@@ -149,6 +197,199 @@ func CallTimeout(d time.Duration) Option {
 	return func(o *stmtOptions) { o.callTimeout = d }
 }
 
+// BatchErrors returns an option to finish the whole array DML (ExecContext with slices),
+// even if some rows fail, and return a *BatchError listing the failed rows.
+//
+// The array DML can have at most 65535 rows with this option, as ODPI-C reports
+// the offsets of the failed rows in 16 bits.
+func BatchErrors() Option {
+	return func(o *stmtOptions) { o.batchErrors = true }
+}
+
+// BatchError is returned by ExecContext with the BatchErrors option,
+// when some rows of the array DML failed.
+//
+// As database/sql drops the Result when an error is returned,
+// the number of successful rows is in RowsAffected.
+type BatchError struct {
+	Errors       []RowError
+	RowsAffected int64
+}
+
+// RowError is the error of one row of the array DML.
+type RowError struct {
+	// Offset is the index of the failed row in the array.
+	Offset int
+	Err    *OraErr
+}
+
+func (be *BatchError) Error() string {
+	if len(be.Errors) == 0 {
+		return "no batch errors"
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d rows failed (%d succeeded): ", len(be.Errors), be.RowsAffected)
+	for i, re := range be.Errors {
+		if i == 3 {
+			fmt.Fprintf(&buf, "; ... (%d more)", len(be.Errors)-i)
+			break
+		}
+		if i != 0 {
+			buf.WriteString("; ")
+		}
+		fmt.Fprintf(&buf, "%d: %v", re.Offset, re.Err)
+	}
+	return buf.String()
+}
+
+// Unwrap returns the first row's error.
+func (be *BatchError) Unwrap() error {
+	if len(be.Errors) == 0 {
+		return nil
+	}
+	return be.Errors[0].Err
+}
+
+// getBatchErrors returns the batch errors after an executeMany in DPI_MODE_EXEC_BATCH_ERRORS mode.
+func (st *statement) getBatchErrors() (*BatchError, error) {
+	var n C.uint32_t
+	if C.dpiStmt_getBatchErrorCount(st.dpiStmt, &n) == C.DPI_FAILURE {
+		return nil, errors.Errorf("getBatchErrorCount: %w", st.getError())
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	errInfos := make([]C.dpiErrorInfo, int(n))
+	if C.dpiStmt_getBatchErrors(st.dpiStmt, n, &errInfos[0]) == C.DPI_FAILURE {
+		return nil, errors.Errorf("getBatchErrors: %w", st.getError())
+	}
+	be := BatchError{Errors: make([]RowError, len(errInfos))}
+	for i, ei := range errInfos {
+		be.Errors[i] = RowError{Offset: int(ei.offset), Err: fromErrorInfo(ei)}
+	}
+	return &be, nil
+}
+
+// maxBatchErrorsRows is the maximum number of rows of an array DML with BatchErrors,
+// as ODPI-C returns the offset of the failed rows in 16 bits only.
+const maxBatchErrorsRows = 1<<16 - 1
+
+// checkBatchErrorsRows returns an error if the failed rows of an array DML with n rows
+// could not be told apart by their offsets.
+func checkBatchErrorsRows(n int) error {
+	if n > maxBatchErrorsRows {
+		return errors.Errorf("BatchErrors supports at most %d rows, got %d: %w", maxBatchErrorsRows, n, ErrNotSupported)
+	}
+	return nil
+}
+
+// ArrayDMLRowCounts returns an option to get the number of affected rows
+// for each iteration of the array DML (ExecContext with slices) into counts.
+//
+// counts is set during ExecContext - to nil if the statement is not an array DML.
+func ArrayDMLRowCounts(counts *[]int64) Option {
+	return func(o *stmtOptions) { o.rowCounts = counts }
+}
+
+// DecodeTypes returns an option to return the columns of the Oracle types registered
+// for the given Go types (see RegisterType) as those Go types, decoded.
+//
+// With Rowid{}, the ROWID columns are returned as Rowid, keeping the ROWID handle for binding,
+// instead of string.
+func DecodeTypes(goTypes ...interface{}) Option {
+	types := make([]reflect.Type, len(goTypes))
+	for i, v := range goTypes {
+		types[i] = reflect.TypeOf(v)
+	}
+	return func(o *stmtOptions) { o.decodeTypes = types }
+}
+
+// Scrollable returns an option to open a scrollable cursor for the query,
+// to be used with the Scroller interface of the returned rows (see QueryScrollable).
+func Scrollable() Option {
+	return func(o *stmtOptions) { o.scrollable = true }
+}
+
+// ReturnedRows is a destination for a RETURNING INTO variable,
+// collecting the values returned by all the iterations of an array DML
+// (ExecContext with slices) into Dest, which must be a pointer to a slice (*[]T).
+//
+// Offsets[i] is the index of the first value returned by the i-th iteration in Dest.
+//
+// Use it as sql.Out{Dest: &ReturnedRows{Dest: &ids}}.
+// A *[][]T destination collects the values per iteration,
+// and a *[]T destination collects the values of all iterations, without offsets.
+type ReturnedRows struct {
+	Dest    interface{}
+	Offsets []int
+}
+
+var byteSliceType = reflect.TypeOf([]byte(nil))
+
+// returnSliceType returns the []T type for *ReturnedRows{Dest: *[]T} and *[][]T destinations,
+// and nil for any other.
+func returnSliceType(dest interface{}) reflect.Type {
+	if rr, ok := dest.(*ReturnedRows); ok {
+		if typ := reflect.TypeOf(rr.Dest); typ != nil && typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Slice {
+			return typ.Elem()
+		}
+		return nil
+	}
+	typ := reflect.TypeOf(dest)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Slice {
+		return nil
+	}
+	// [][]byte is a slice of RAWs
+	if typ = typ.Elem().Elem(); typ.Kind() != reflect.Slice || typ == byteSliceType {
+		return nil
+	}
+	return typ
+}
+
+// getReturnedAll collects the returned data of all iterations into the i-th destination,
+// which must be a *ReturnedRows, *[][]T or *[]T.
+func (st *statement) getReturnedAll(i int, get dataGetter) error {
+	iters := 1
+	if !st.PlSQLArrays() && st.arrLen > 0 {
+		iters = st.arrLen
+	}
+	typ := st.returnSlices[i]
+	if typ == nil {
+		typ = reflect.TypeOf(st.dests[i]).Elem()
+	}
+	all := reflect.MakeSlice(typ, 0, iters)
+	perIter := reflect.MakeSlice(reflect.SliceOf(typ), iters, iters)
+	offsets := make([]int, iters)
+	for pos := 0; pos < iters; pos++ {
+		var n C.uint32_t
+		var data *C.dpiData
+		if C.dpiVar_getReturnedData(st.vars[i], C.uint32_t(pos), &n, &data) == C.DPI_FAILURE {
+			return errors.Errorf("%d.getReturnedData: %w", pos, st.getError())
+		}
+		rv := reflect.New(typ)
+		if n != 0 {
+			if err := get(rv.Interface(), (*(*[maxArraySize]C.dpiData)(unsafe.Pointer(data)))[:int(n):int(n)]); err != nil {
+				return errors.Errorf("%d.get: %w", pos, err)
+			}
+		}
+		offsets[pos] = all.Len()
+		all = reflect.AppendSlice(all, rv.Elem())
+		perIter.Index(pos).Set(rv.Elem())
+	}
+	switch dest := st.dests[i].(type) {
+	case *ReturnedRows:
+		reflect.ValueOf(dest.Dest).Elem().Set(all)
+		dest.Offsets = offsets
+	default:
+		if st.returnSlices[i] != nil {
+			reflect.ValueOf(dest).Elem().Set(perIter)
+		} else {
+			reflect.ValueOf(dest).Elem().Set(all)
+		}
+	}
+	return nil
+}
+
 const minChunkSize = 1 << 16
 
 var _ = driver.Stmt((*statement)(nil))
@@ -158,20 +399,38 @@ var _ = driver.NamedValueChecker((*statement)(nil))
 
 type statement struct {
 	stmtOptions
-	columns  []Column
-	isSlice  []bool
-	gets     []dataGetter
-	dests    []interface{}
-	data     [][]C.dpiData
-	vars     []*C.dpiVar
-	varInfos []varInfo
-	query    string
+	columns []Column
+	isSlice []bool
+	// returnSlices holds the []T type of the RETURNING INTO destinations
+	// collecting the values of all iterations ([][]T and ReturnedRows).
+	returnSlices []reflect.Type
+	gets         []dataGetter
+	dests        []interface{}
+	data         [][]C.dpiData
+	vars         []*C.dpiVar
+	varInfos     []varInfo
+	// bindObjects are the objects created for binding (SQLCollection, Geometry),
+	// released when the binding is done, as the dpiVar keeps its own reference.
+	bindObjects []*Object
+	query       string
 	sync.Mutex
 	arrLen int
 	*conn
-	dpiStmt     *C.dpiStmt
-	isReturning bool
+	dpiStmt      *C.dpiStmt
+	isReturning  bool
+	isScrollable bool
+	// tzOverride is the time zone given with ContextWithTimeZone.
+	tzOverride *time.Location
+}
+
+// location returns the time zone of the DATE and TIMESTAMP values.
+func (st *statement) location() *time.Location {
+	if st.tzOverride != nil {
+		return st.tzOverride
+	}
+	return st.conn.timeZone
 }
+
 type dataGetter func(v interface{}, data []C.dpiData) error
 
 // Close closes the statement.
@@ -215,6 +474,7 @@ func (st *statement) cleanup() error {
 		return nil
 	}
 
+	st.closeBindObjects()
 	for _, v := range st.vars {
 		C.dpiVar_release(v)
 	}
@@ -290,6 +550,12 @@ func (st *statement) ExecContext(ctx context.Context, args []driver.NamedValue)
 		return driver.ResultNoRows, nil
 	}
 	st.isReturning = false
+	st.tzOverride, _ = ctx.Value(timeZoneCtxKey).(*time.Location)
+	if st.TimeZoneRegions() {
+		if err = st.conn.initTZRegions(ctx); err != nil {
+			return nil, closeIfBadConn(err)
+		}
+	}
 
 	st.conn.RLock()
 	defer st.conn.RUnlock()
@@ -304,6 +570,17 @@ func (st *statement) ExecContext(ctx context.Context, args []driver.NamedValue)
 	if !st.inTransaction {
 		mode |= C.DPI_MODE_EXEC_COMMIT_ON_SUCCESS
 	}
+	batchErrors := st.BatchErrors() && !st.PlSQLArrays() && st.arrLen > 0
+	if batchErrors {
+		if err = checkBatchErrorsRows(st.arrLen); err != nil {
+			return nil, err
+		}
+		mode |= C.DPI_MODE_EXEC_BATCH_ERRORS
+	}
+	rowCounts := st.resetRowCounts()
+	if rowCounts {
+		mode |= C.DPI_MODE_EXEC_ARRAY_DML_ROWCOUNTS
+	}
 	st.setCallTimeout(ctx)
 
 	done := make(chan error, 1)
@@ -391,6 +668,13 @@ func (st *statement) ExecContext(ctx context.Context, args []driver.NamedValue)
 		}
 	}
 
+	var batchErr *BatchError
+	if batchErrors {
+		if batchErr, err = st.getBatchErrors(); err != nil {
+			return nil, closeIfBadConn(err)
+		}
+	}
+
 	if Log != nil {
 		Log("gets", st.gets, "dests", st.dests)
 	}
@@ -398,6 +682,12 @@ func (st *statement) ExecContext(ctx context.Context, args []driver.NamedValue)
 		if get == nil {
 			continue
 		}
+		if st.isReturning && st.isSlice[i] {
+			if err = st.getReturnedAll(i, get); err != nil {
+				return nil, errors.Errorf("%d. getReturned: %w", i, closeIfBadConn(err))
+			}
+			continue
+		}
 		if st.isReturning {
 			var n C.uint32_t
 			data := &st.data[i][0]
@@ -439,11 +729,46 @@ func (st *statement) ExecContext(ctx context.Context, args []driver.NamedValue)
 	}
 	var count C.uint64_t
 	if C.dpiStmt_getRowCount(st.dpiStmt, &count) == C.DPI_FAILURE {
+		if batchErr != nil {
+			return nil, batchErr
+		}
 		return nil, nil
 	}
+	if batchErr != nil {
+		batchErr.RowsAffected = int64(count)
+		return nil, batchErr
+	}
+	if rowCounts {
+		var n C.uint32_t
+		var counts *C.uint64_t
+		if C.dpiStmt_getRowCounts(st.dpiStmt, &n, &counts) == C.DPI_FAILURE {
+			return nil, errors.Errorf("getRowCounts: %w", closeIfBadConn(st.getError()))
+		}
+		// C.uint64_t is uint64
+		st.setRowCounts((*[maxArraySize]uint64)(unsafe.Pointer(counts))[:int(n):int(n)])
+	}
 	return driver.RowsAffected(count), nil
 }
 
+// resetRowCounts clears the counts of the ArrayDMLRowCounts option,
+// and reports whether they are to be set by the execution of an array DML.
+func (st *statement) resetRowCounts() bool {
+	if !st.ArrayDMLRowCounts() {
+		return false
+	}
+	*st.rowCounts = nil
+	return !st.PlSQLArrays() && st.arrLen > 0
+}
+
+// setRowCounts copies the row counts into the ArrayDMLRowCounts option's destination.
+func (st *statement) setRowCounts(counts []uint64) {
+	rc := make([]int64, len(counts))
+	for i, c := range counts {
+		rc[i] = int64(c)
+	}
+	*st.rowCounts = rc
+}
+
 // QueryContext executes a query that may return rows, such as a SELECT.
 //
 // QueryContext must honor the context timeout and return when it is canceled.
@@ -464,6 +789,12 @@ func (st *statement) QueryContext(ctx context.Context, args []driver.NamedValue)
 	st.Lock()
 	defer st.Unlock()
 	st.isReturning = false
+	st.tzOverride, _ = ctx.Value(timeZoneCtxKey).(*time.Location)
+	if st.TimeZoneRegions() && st.query != getConnection && st.query != wrapResultset {
+		if err := st.conn.initTZRegions(ctx); err != nil {
+			return nil, closeIfBadConn(err)
+		}
+	}
 	st.conn.RLock()
 	defer st.conn.RUnlock()
 
@@ -481,6 +812,12 @@ func (st *statement) QueryContext(ctx context.Context, args []driver.NamedValue)
 		return args[0].Value.(driver.Rows), nil
 	}
 
+	if st.Scrollable() && !st.isScrollable {
+		if err := st.prepareScrollable(); err != nil {
+			return nil, closeIfBadConn(err)
+		}
+	}
+
 	//fmt.Printf("QueryContext(%+v)\n", args)
 	// bind variables
 	if err := st.bindVars(args, Log); err != nil {
@@ -547,6 +884,22 @@ func (st *statement) QueryContext(ctx context.Context, args []driver.NamedValue)
 	return rows, closeIfBadConn(err)
 }
 
+// prepareScrollable replaces the statement handle with a scrollable one,
+// as only the prepare can make a cursor scrollable.
+func (st *statement) prepareScrollable() error {
+	cSQL := C.CString(st.query)
+	defer C.free(unsafe.Pointer(cSQL))
+	var dpiStmt *C.dpiStmt
+	if C.dpiConn_prepareStmt(st.dpiConn, 1, cSQL, C.uint32_t(len(st.query)), nil, 0,
+		(**C.dpiStmt)(unsafe.Pointer(&dpiStmt)),
+	) == C.DPI_FAILURE {
+		return maybeBadConn(errors.Errorf("Prepare (scrollable): %s: %w", st.query, st.getError()), nil)
+	}
+	C.dpiStmt_release(st.dpiStmt)
+	st.dpiStmt, st.isScrollable = dpiStmt, true
+	return nil
+}
+
 // NumInput returns the number of placeholder parameters.
 //
 // If NumInput returns >= 0, the sql package will sanity check
@@ -602,6 +955,232 @@ func (st *statement) setCallTimeout(ctx context.Context) {
 	st.conn.setCallTimeout(ctx)
 }
 
+// OracleType is an Oracle type to bind a parameter as, see As.
+type OracleType uint8
+
+// The Oracle types usable with As.
+const (
+	TypeVarchar = OracleType(iota + 1)
+	TypeNVarchar
+	TypeChar
+	TypeNChar
+	TypeLong
+	TypeClob
+	TypeNClob
+	TypeRaw
+	TypeLongRaw
+	TypeBlob
+	TypeNumber
+	TypeBinaryFloat
+	TypeBinaryDouble
+	TypeDate
+	TypeTimestamp
+	TypeTimestampTZ
+	TypeTimestampLTZ
+)
+
+func (t OracleType) oracleTypeNum() (C.dpiOracleTypeNum, error) {
+	switch t {
+	case TypeVarchar:
+		return C.DPI_ORACLE_TYPE_VARCHAR, nil
+	case TypeNVarchar:
+		return C.DPI_ORACLE_TYPE_NVARCHAR, nil
+	case TypeChar:
+		return C.DPI_ORACLE_TYPE_CHAR, nil
+	case TypeNChar:
+		return C.DPI_ORACLE_TYPE_NCHAR, nil
+	case TypeLong:
+		return C.DPI_ORACLE_TYPE_LONG_VARCHAR, nil
+	case TypeClob:
+		return C.DPI_ORACLE_TYPE_CLOB, nil
+	case TypeNClob:
+		return C.DPI_ORACLE_TYPE_NCLOB, nil
+	case TypeRaw:
+		return C.DPI_ORACLE_TYPE_RAW, nil
+	case TypeLongRaw:
+		return C.DPI_ORACLE_TYPE_LONG_RAW, nil
+	case TypeBlob:
+		return C.DPI_ORACLE_TYPE_BLOB, nil
+	case TypeNumber:
+		return C.DPI_ORACLE_TYPE_NUMBER, nil
+	case TypeBinaryFloat:
+		return C.DPI_ORACLE_TYPE_NATIVE_FLOAT, nil
+	case TypeBinaryDouble:
+		return C.DPI_ORACLE_TYPE_NATIVE_DOUBLE, nil
+	case TypeDate:
+		return C.DPI_ORACLE_TYPE_DATE, nil
+	case TypeTimestamp:
+		return C.DPI_ORACLE_TYPE_TIMESTAMP, nil
+	case TypeTimestampTZ:
+		return C.DPI_ORACLE_TYPE_TIMESTAMP_TZ, nil
+	case TypeTimestampLTZ:
+		return C.DPI_ORACLE_TYPE_TIMESTAMP_LTZ, nil
+	}
+	return 0, errors.Errorf("unknown OracleType %d", t)
+}
+
+// TypedValue is a parameter with an explicit Oracle type, see As.
+type TypedValue struct {
+	// Value is the value to be bound (or the destination, as sql.Out.Dest), can be a slice, too.
+	Value interface{}
+	Type  OracleType
+	// Size is the buffer size in bytes, overriding the one computed from Value, if not zero.
+	Size int
+}
+
+// As returns the value, to be bound as the given Oracle type,
+// instead of the one derived from the Go type:
+// for example a long string as CLOB, a []byte as BLOB or a string as NVARCHAR2.
+//
+// The Go type still must be convertible to the Oracle type, else binding returns an error:
+// strings and []byte (and slices of them) are written into temporary LOBs,
+// float64 is converted to float32 for BINARY_FLOAT.
+// Use it as sql.Out{Dest: As(&dest, TypeClob)} for OUT parameters - a *string or *[]byte gets the whole LOB.
+func As(value interface{}, typ OracleType) TypedValue {
+	return TypedValue{Value: value, Type: typ}
+}
+
+// inValue converts the IN value to the Go type to be bound as the Oracle type:
+// strings and []byte to temporary LOBs, float64 to float32.
+func (t OracleType) inValue(value interface{}) interface{} {
+	switch t {
+	case TypeClob, TypeNClob, TypeBlob:
+		newLob := func(r io.Reader) Lob {
+			return Lob{Reader: r, IsClob: t == TypeClob, IsNClob: t == TypeNClob}
+		}
+		switch x := value.(type) {
+		case string:
+			return newLob(strings.NewReader(x))
+		case *string:
+			if x == nil {
+				return Lob{}
+			}
+			return newLob(strings.NewReader(*x))
+		case []byte:
+			if x == nil {
+				return Lob{}
+			}
+			return newLob(bytes.NewReader(x))
+		case *[]byte:
+			if x == nil || *x == nil {
+				return Lob{}
+			}
+			return newLob(bytes.NewReader(*x))
+		case []string:
+			lobs := make([]Lob, len(x))
+			for i, s := range x {
+				lobs[i] = newLob(strings.NewReader(s))
+			}
+			return lobs
+		case [][]byte:
+			lobs := make([]Lob, len(x))
+			for i, b := range x {
+				lobs[i] = Lob{IsClob: t == TypeClob, IsNClob: t == TypeNClob}
+				if b != nil {
+					lobs[i].Reader = bytes.NewReader(b)
+				}
+			}
+			return lobs
+		}
+	case TypeBinaryFloat:
+		switch x := value.(type) {
+		case float64:
+			return float32(x)
+		case []float64:
+			fs := make([]float32, len(x))
+			for i, f := range x {
+				fs[i] = float32(f)
+			}
+			return fs
+		}
+	}
+	return value
+}
+
+// hintNativeType reports whether a variable of the Oracle type can be bound with the native type.
+func hintNativeType(typ C.dpiOracleTypeNum, natTyp C.dpiNativeTypeNum) bool {
+	switch typ {
+	case C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_ORACLE_TYPE_NVARCHAR,
+		C.DPI_ORACLE_TYPE_CHAR, C.DPI_ORACLE_TYPE_NCHAR, C.DPI_ORACLE_TYPE_LONG_VARCHAR,
+		C.DPI_ORACLE_TYPE_RAW, C.DPI_ORACLE_TYPE_LONG_RAW:
+		return natTyp == C.DPI_NATIVE_TYPE_BYTES
+	case C.DPI_ORACLE_TYPE_CLOB, C.DPI_ORACLE_TYPE_NCLOB, C.DPI_ORACLE_TYPE_BLOB:
+		return natTyp == C.DPI_NATIVE_TYPE_LOB
+	case C.DPI_ORACLE_TYPE_NUMBER:
+		switch natTyp {
+		case C.DPI_NATIVE_TYPE_BYTES, C.DPI_NATIVE_TYPE_INT64, C.DPI_NATIVE_TYPE_UINT64, C.DPI_NATIVE_TYPE_DOUBLE:
+			return true
+		}
+	case C.DPI_ORACLE_TYPE_NATIVE_FLOAT:
+		return natTyp == C.DPI_NATIVE_TYPE_FLOAT
+	case C.DPI_ORACLE_TYPE_NATIVE_DOUBLE:
+		return natTyp == C.DPI_NATIVE_TYPE_DOUBLE
+	case C.DPI_ORACLE_TYPE_DATE, C.DPI_ORACLE_TYPE_TIMESTAMP,
+		C.DPI_ORACLE_TYPE_TIMESTAMP_TZ, C.DPI_ORACLE_TYPE_TIMESTAMP_LTZ:
+		return natTyp == C.DPI_NATIVE_TYPE_TIMESTAMP
+	}
+	return false
+}
+
+// applyHint sets the Oracle type given with As on the bind variable,
+// with the native type and the getter it needs.
+//
+// The LOB OUT parameters with a *string or *[]byte destination are read whole.
+func (st *statement) applyHint(info *argInfo, get *dataGetter, dest interface{}) error {
+	h := info.hint
+	typ, err := h.Type.oracleTypeNum()
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case C.DPI_ORACLE_TYPE_CLOB, C.DPI_ORACLE_TYPE_NCLOB, C.DPI_ORACLE_TYPE_BLOB:
+		if !info.isOut {
+			break
+		}
+		switch dest.(type) {
+		case *string, *[]byte:
+			if !info.isIn && info.natTyp == C.DPI_NATIVE_TYPE_BYTES {
+				info.natTyp, info.bufSize = C.DPI_NATIVE_TYPE_LOB, 0
+			}
+			*get = st.lobBytesGetter(typ != C.DPI_ORACLE_TYPE_BLOB)
+		}
+	}
+	if !hintNativeType(typ, info.natTyp) {
+		return errors.Errorf("%T as OracleType %d: %w", h.Value, h.Type, ErrNotSupported)
+	}
+	info.typ = typ
+	if h.Size > 0 {
+		info.bufSize = h.Size
+	}
+	return nil
+}
+
+// lobBytesGetter returns a getter which reads the whole LOB into a *string or *[]byte.
+func (c *conn) lobBytesGetter(isClob bool) dataGetter {
+	return func(v interface{}, data []C.dpiData) error {
+		L := Lob{IsClob: isClob}
+		if err := c.dataGetLOB(&L, data); err != nil {
+			return err
+		}
+		var b []byte
+		if L.Reader != nil {
+			var err error
+			if b, err = ioutil.ReadAll(L); err != nil {
+				return err
+			}
+		}
+		switch x := v.(type) {
+		case *string:
+			*x = string(b)
+		case *[]byte:
+			*x = b
+		default:
+			return errors.Errorf("LOB into %T: %w", v, ErrNotSupported)
+		}
+		return nil
+	}
+}
+
 type argInfo struct {
 	objType     *C.dpiObjectType
 	set         dataSetter
@@ -609,13 +1188,41 @@ type argInfo struct {
 	typ         C.dpiOracleTypeNum
 	natTyp      C.dpiNativeTypeNum
 	isIn, isOut bool
+	// hint is the type given with As
+	hint *TypedValue
+}
+
+// setNulls wraps info.set to set the NULL elements after setting the values.
+func (info *argInfo) setNulls(nulls []bool) {
+	set := info.set
+	info.set = func(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
+		if err := set(dv, data, vv); err != nil {
+			return err
+		}
+		for i, isNull := range nulls {
+			if isNull && i < len(data) {
+				data[i].isNull = 1
+			}
+		}
+		return nil
+	}
 }
 
 // bindVars binds the given args into new variables.
+// closeBindObjects releases the objects created for binding.
+func (st *statement) closeBindObjects() {
+	for _, o := range st.bindObjects {
+		o.Close()
+	}
+	st.bindObjects = st.bindObjects[:0]
+}
+
 func (st *statement) bindVars(args []driver.NamedValue, Log logFunc) error {
 	if Log != nil {
 		Log("enter", "bindVars", "args", args)
 	}
+	// on error, too, as a later argument may fail after the objects are created
+	defer st.closeBindObjects()
 	if cap(st.vars) < len(args) || cap(st.varInfos) < len(args) {
 		for i, v := range st.vars {
 			if v != nil {
@@ -655,6 +1262,11 @@ func (st *statement) bindVars(args []driver.NamedValue, Log logFunc) error {
 	} else {
 		st.isSlice = st.isSlice[:len(args)]
 	}
+	if cap(st.returnSlices) < len(args) {
+		st.returnSlices = make([]reflect.Type, len(args))
+	} else {
+		st.returnSlices = st.returnSlices[:len(args)]
+	}
 
 	rArgs := make([]reflect.Value, len(args))
 	minArrLen, maxArrLen := -1, -1
@@ -680,6 +1292,9 @@ func (st *statement) bindVars(args []driver.NamedValue, Log logFunc) error {
 			info.isIn, info.isOut = out.In, true
 			value = out.Dest
 		}
+		if tv, ok := value.(TypedValue); ok {
+			info.hint, value = &tv, tv.Value
+		}
 		st.dests[i] = value
 		rv := reflect.ValueOf(value)
 		if info.isOut {
@@ -692,14 +1307,22 @@ func (st *statement) bindVars(args []driver.NamedValue, Log logFunc) error {
 			}
 		}
 		st.isSlice[i] = false
+		st.returnSlices[i] = nil
 		rArgs[i] = rv
 		if rv.Kind() == reflect.Ptr {
 			// deref in rArgs, but NOT value!
 			rArgs[i] = rv.Elem()
 		}
+		if info.isOut && !info.isIn {
+			if st.returnSlices[i] = returnSliceType(st.dests[i]); st.returnSlices[i] != nil {
+				st.isSlice[i] = true
+				continue
+			}
+		}
 		if _, isByteSlice := value.([]byte); !isByteSlice {
 			st.isSlice[i] = rArgs[i].Kind() == reflect.Slice
-			if !st.PlSQLArrays() && st.isSlice[i] {
+			// OUT slices are sized by the IN slices
+			if !st.PlSQLArrays() && st.isSlice[i] && info.isIn {
 				n := rArgs[i].Len()
 				if minArrLen == -1 || n < minArrLen {
 					minArrLen = n
@@ -739,11 +1362,24 @@ func (st *statement) bindVars(args []driver.NamedValue, Log logFunc) error {
 	for i := range args {
 		info := &(infos[i])
 		value := st.dests[i]
+		if typ := st.returnSlices[i]; typ != nil {
+			// bind as *[]T
+			value = reflect.New(typ).Interface()
+		}
+
+		if h := info.hint; h != nil && info.isIn {
+			value = h.Type.inValue(value)
+		}
 
 		var err error
 		if value, err = st.bindVarTypeSwitch(info, &(st.gets[i]), value); err != nil {
 			return errors.Errorf("%d. arg: %w", i+1, err)
 		}
+		if info.hint != nil {
+			if err = st.applyHint(info, &(st.gets[i]), st.dests[i]); err != nil {
+				return errors.Errorf("%d. arg: %w", i+1, err)
+			}
+		}
 
 		var rv reflect.Value
 		if st.isSlice[i] {
@@ -853,8 +1489,36 @@ func (st *statement) bindVarTypeSwitch(info *argInfo, get *dataGetter, value int
 	}
 	vlr, isValuer := value.(driver.Valuer)
 
+	// the types registered with RegisterType are bound as their encoded form
+	if base, tc, nulls, wrapGet, err := registered(value, info.isOut); err != nil {
+		return value, err
+	} else if tc != nil {
+		if value, err = st.bindVarTypeSwitch(info, get, base); err != nil {
+			return value, err
+		}
+		info.typ = tc.typ
+		info.setNulls(nulls)
+		if info.isOut && *get != nil {
+			*get = wrapGet(*get)
+		}
+		return value, nil
+	}
+
+	// *T, []*T, sql.NullT and []sql.NullT are bound as T or []T, with NULLs
+	if base, nulls, wrapGet, ok := nullable(value, info.isOut); ok {
+		var err error
+		if value, err = st.bindVarTypeSwitch(info, get, base); err != nil {
+			return value, err
+		}
+		info.setNulls(nulls)
+		if info.isOut && *get != nil {
+			*get = wrapGet(*get)
+		}
+		return value, nil
+	}
+
 	switch value.(type) {
-	case *driver.Rows:
+	case *driver.Rows, *rows, time.Duration, NString, XML:
 	default:
 		var magic bool
 		rv := reflect.ValueOf(value)
@@ -935,14 +1599,17 @@ func (st *statement) bindVarTypeSwitch(info *argInfo, get *dataGetter, value int
 	switch v := value.(type) {
 	case Lob, []Lob:
 		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_BLOB, C.DPI_NATIVE_TYPE_LOB
-		var isClob bool
+		var isClob, isNClob bool
 		switch v := v.(type) {
 		case Lob:
-			isClob = v.IsClob
+			isClob, isNClob = v.IsClob, v.IsNClob
 		case []Lob:
 			isClob = len(v) > 0 && v[0].IsClob
+			isNClob = len(v) > 0 && v[0].IsNClob
 		}
-		if isClob {
+		if isNClob {
+			info.typ = C.DPI_ORACLE_TYPE_NCLOB
+		} else if isClob {
 			info.typ = C.DPI_ORACLE_TYPE_CLOB
 		}
 		info.set = st.dataSetLOB
@@ -955,6 +1622,9 @@ func (st *statement) bindVarTypeSwitch(info *argInfo, get *dataGetter, value int
 		if info.isOut {
 			*get = st.dataGetStmt
 		}
+	case *rows, Cursor:
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_STMT, C.DPI_NATIVE_TYPE_STMT
+		info.set = st.dataSetStmt
 	case int, []int:
 		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_NUMBER, C.DPI_NATIVE_TYPE_INT64
 		if !nilPtr {
@@ -1068,6 +1738,33 @@ func (st *statement) bindVarTypeSwitch(info *argInfo, get *dataGetter, value int
 			*get = dataGetBytes
 		}
 
+	case Rowid, []Rowid:
+		// the fetched ROWIDs are bound as is, the ones made from text as string
+		if !info.isIn || hasRowidHandles(v) {
+			info.typ, info.natTyp = C.DPI_ORACLE_TYPE_ROWID, C.DPI_NATIVE_TYPE_ROWID
+			info.set = st.dataSetRowid
+			if info.isOut {
+				*get = st.dataGetRowid
+			}
+			break
+		}
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_NATIVE_TYPE_BYTES
+		switch v := v.(type) {
+		case Rowid:
+			info.bufSize = len(v.s)
+		case []Rowid:
+			for _, r := range v {
+				if n := len(r.s); n > info.bufSize {
+					info.bufSize = n
+				}
+			}
+		}
+		info.set = dataSetBytes
+		if info.isOut {
+			info.bufSize = 4000
+			*get = dataGetBytes
+		}
+
 	case string, []string, nil:
 		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_NATIVE_TYPE_BYTES
 		switch v := v.(type) {
@@ -1086,11 +1783,83 @@ func (st *statement) bindVarTypeSwitch(info *argInfo, get *dataGetter, value int
 			*get = dataGetBytes
 		}
 
+	case JSON:
+		b, err := v.bindValue()
+		if err != nil {
+			return value, err
+		}
+		return st.bindVarTypeSwitch(info, get, b)
+	case []JSON:
+		b, err := jsonBindValues(v)
+		if err != nil {
+			return value, err
+		}
+		return st.bindVarTypeSwitch(info, get, b)
+
+	case XML, []XML:
+		// the long XML texts are bound as CLOB, which is converted to XMLType just as VARCHAR2
+		if x, ok := v.(XML); ok && !info.isOut && len(x) > maxVarcharBind {
+			return st.bindVarTypeSwitch(info, get, Lob{Reader: x.Reader(), IsClob: true})
+		}
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_NATIVE_TYPE_BYTES
+		switch v := v.(type) {
+		case XML:
+			info.bufSize = len(v)
+		case []XML:
+			for _, s := range v {
+				if n := len(s); n > info.bufSize {
+					info.bufSize = n
+				}
+			}
+		}
+		info.set = dataSetBytes
+		if info.isOut {
+			info.bufSize = maxVarcharBind
+			*get = dataGetBytes
+		}
+
+	case NString, []NString:
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_NVARCHAR, C.DPI_NATIVE_TYPE_BYTES
+		switch v := v.(type) {
+		case NString:
+			info.bufSize = 4 * len(v)
+		case []NString:
+			for _, s := range v {
+				if n := 4 * len(s); n > info.bufSize {
+					info.bufSize = n
+				}
+			}
+		}
+		info.set = dataSetBytes
+		if info.isOut {
+			info.bufSize = 32767
+			*get = dataGetBytes
+		}
+
 	case time.Time, []time.Time:
+		if st.TimeZoneRegions() && !info.isOut {
+			if s, ok := tzRegionStrings(v); ok {
+				return st.bindVarTypeSwitch(info, get, s)
+			}
+		}
 		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_DATE, C.DPI_NATIVE_TYPE_TIMESTAMP
-		info.set = st.conn.dataSetTime
+		info.set = st.dataSetTime
+		if info.isOut {
+			*get = st.dataGetTime
+		}
+
+	case time.Duration, []time.Duration:
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_INTERVAL_DS, C.DPI_NATIVE_TYPE_INTERVAL_DS
+		info.set = dataSetIntervalDS
+		if info.isOut {
+			*get = dataGetIntervalDS
+		}
+
+	case IntervalYM, []IntervalYM:
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_INTERVAL_YM, C.DPI_NATIVE_TYPE_INTERVAL_YM
+		info.set = dataSetIntervalYM
 		if info.isOut {
-			*get = st.conn.dataGetTime
+			*get = dataGetIntervalYM
 		}
 
 	case Object:
@@ -1119,6 +1888,63 @@ func (st *statement) bindVarTypeSwitch(info *argInfo, get *dataGetter, value int
 			*get = st.dataGetObject
 		}
 
+	case SQLCollection:
+		if info.isOut {
+			return value, errors.Errorf("SQLCollection can only be an IN parameter: %w", ErrNotSupported)
+		}
+		coll, err := v.newCollection(st.conn)
+		if err != nil {
+			return value, err
+		}
+		st.bindObjects = append(st.bindObjects, coll.Object)
+		info.objType = coll.ObjectType.dpiObjectType
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_OBJECT, C.DPI_NATIVE_TYPE_OBJECT
+		info.set = func(dv *C.dpiVar, data []C.dpiData, _ interface{}) error {
+			return st.dataSetObject(dv, data, coll.Object)
+		}
+
+	case Geometry, []Geometry:
+		typ, err := st.conn.GetObjectType("MDSYS.SDO_GEOMETRY")
+		if err != nil {
+			return value, err
+		}
+		info.objType = typ.dpiObjectType
+		info.typ, info.natTyp = C.DPI_ORACLE_TYPE_OBJECT, C.DPI_NATIVE_TYPE_OBJECT
+		info.set = st.dataSetObject
+		if info.isOut {
+			*get = func(v interface{}, data []C.dpiData) error {
+				g, ok := v.(*Geometry)
+				if !ok {
+					return errors.Errorf("Geometry OUT parameter needs *Geometry, got %T", v)
+				}
+				d := Data{ObjectType: typ, dpiData: &data[0]}
+				return g.Scan(d.GetObject())
+			}
+		}
+		if !info.isIn {
+			break
+		}
+		gs, isSlice := v.([]Geometry)
+		if !isSlice {
+			gs = []Geometry{v.(Geometry)}
+		}
+		// the NULL geometries remain zero Objects
+		objs := make([]Object, len(gs))
+		for i, g := range gs {
+			if g.IsNull() {
+				continue
+			}
+			o, err := g.NewObject(typ)
+			if err != nil {
+				return value, errors.Errorf("%d. geometry: %w", i, err)
+			}
+			objs[i] = *o
+			st.bindObjects = append(st.bindObjects, &objs[i])
+		}
+		info.set = func(dv *C.dpiVar, data []C.dpiData, _ interface{}) error {
+			return st.dataSetObject(dv, data, objs)
+		}
+
 	default:
 		if !isValuer {
 			return value, errors.Errorf("unknown type %T", value)
@@ -1179,6 +2005,7 @@ func dataSetBool(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
 	}
 	if bb, ok := vv.([]bool); ok {
 		for i, v := range bb {
+			b = 0
 			if v {
 				b = 1
 			}
@@ -1191,13 +2018,13 @@ func dataSetBool(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
 	}
 	return nil
 }
-func (c *conn) dataGetTime(v interface{}, data []C.dpiData) error {
+func (st *statement) dataGetTime(v interface{}, data []C.dpiData) error {
 	if x, ok := v.(*time.Time); ok {
 		if len(data) == 0 || data[0].isNull == 1 {
 			*x = time.Time{}
 			return nil
 		}
-		c.dataGetTimeC(x, &data[0])
+		st.dataGetTimeC(x, &data[0])
 		return nil
 	}
 	slice := v.(*[]time.Time)
@@ -1208,18 +2035,18 @@ func (c *conn) dataGetTime(v interface{}, data []C.dpiData) error {
 		*slice = make([]time.Time, n)
 	}
 	for i := range data {
-		c.dataGetTimeC(&((*slice)[i]), &data[i])
+		st.dataGetTimeC(&((*slice)[i]), &data[i])
 	}
 	return nil
 }
 
-func (c *conn) dataGetTimeC(t *time.Time, data *C.dpiData) {
+func (st *statement) dataGetTimeC(t *time.Time, data *C.dpiData) {
 	if data.isNull == 1 {
 		*t = time.Time{}
 		return
 	}
 	ts := C.dpiData_getTimestamp(data)
-	tz := c.timeZone
+	tz := st.location()
 	if ts.tzHourOffset != 0 || ts.tzMinuteOffset != 0 {
 		tz = timeZoneFor(ts.tzHourOffset, ts.tzMinuteOffset)
 	}
@@ -1233,7 +2060,7 @@ func (c *conn) dataGetTimeC(t *time.Time, data *C.dpiData) {
 	)
 }
 
-func (c *conn) dataSetTime(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
+func (st *statement) dataSetTime(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
 	if vv == nil {
 		return dataSetNull(dv, data, nil)
 	}
@@ -1249,14 +2076,16 @@ func (c *conn) dataSetTime(dv *C.dpiVar, data []C.dpiData, vv interface{}) error
 			return nil
 		}
 	}
-	tzHour, tzMin := C.int8_t(c.tzOffSecs/3600), C.int8_t((c.tzOffSecs%3600)/60)
+	tz := st.location()
 	for i, t := range times {
 		if t.IsZero() {
 			data[i].isNull = 1
 			continue
 		}
 		data[i].isNull = 0
-		t = t.In(c.timeZone)
+		t = t.In(tz)
+		_, off := t.Zone()
+		tzHour, tzMin := C.int8_t(off/3600), C.int8_t((off%3600)/60)
 		Y, M, D := t.Date()
 		h, m, s := t.Clock()
 		C.dpiData_setTimestamp(&data[i],
@@ -1268,6 +2097,78 @@ func (c *conn) dataSetTime(dv *C.dpiVar, data []C.dpiData, vv interface{}) error
 	return nil
 }
 
+func dataGetIntervalDS(v interface{}, data []C.dpiData) error {
+	if x, ok := v.(*time.Duration); ok {
+		if len(data) == 0 || data[0].isNull == 1 {
+			*x = 0
+			return nil
+		}
+		*x = getIntervalDS(&data[0])
+		return nil
+	}
+	slice := v.(*[]time.Duration)
+	*slice = (*slice)[:0]
+	for i := range data {
+		if data[i].isNull == 1 {
+			*slice = append(*slice, 0)
+			continue
+		}
+		*slice = append(*slice, getIntervalDS(&data[i]))
+	}
+	return nil
+}
+
+func dataSetIntervalDS(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
+	switch x := vv.(type) {
+	case time.Duration:
+		setIntervalDS(&data[0], x)
+	case []time.Duration:
+		for i, d := range x {
+			setIntervalDS(&data[i], d)
+		}
+	default:
+		return dataSetNull(dv, data, nil)
+	}
+	return nil
+}
+
+func dataGetIntervalYM(v interface{}, data []C.dpiData) error {
+	if x, ok := v.(*IntervalYM); ok {
+		if len(data) == 0 || data[0].isNull == 1 {
+			*x = IntervalYM{}
+			return nil
+		}
+		ym := C.dpiData_getIntervalYM(&data[0])
+		*x = IntervalYM{Years: int(ym.years), Months: int(ym.months)}
+		return nil
+	}
+	slice := v.(*[]IntervalYM)
+	*slice = (*slice)[:0]
+	for i := range data {
+		if data[i].isNull == 1 {
+			*slice = append(*slice, IntervalYM{})
+			continue
+		}
+		ym := C.dpiData_getIntervalYM(&data[i])
+		*slice = append(*slice, IntervalYM{Years: int(ym.years), Months: int(ym.months)})
+	}
+	return nil
+}
+
+func dataSetIntervalYM(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
+	switch x := vv.(type) {
+	case IntervalYM:
+		C.dpiData_setIntervalYM(&data[0], C.int32_t(x.Years), C.int32_t(x.Months))
+	case []IntervalYM:
+		for i, ym := range x {
+			C.dpiData_setIntervalYM(&data[i], C.int32_t(ym.Years), C.int32_t(ym.Months))
+		}
+	default:
+		return dataSetNull(dv, data, nil)
+	}
+	return nil
+}
+
 func dataGetNumber(v interface{}, data []C.dpiData) error {
 	switch x := v.(type) {
 	case *int:
@@ -1589,6 +2490,60 @@ func dataGetBytes(v interface{}, data []C.dpiData) error {
 			*x = append(*x, Number(((*[32767]byte)(unsafe.Pointer(b.ptr)))[:b.length:b.length]))
 		}
 
+	case *Rowid:
+		if len(data) == 0 || data[0].isNull == 1 {
+			*x = Rowid{}
+			return nil
+		}
+		b := C.dpiData_getBytes(&data[0])
+		*x = NewRowid(C.GoStringN(b.ptr, C.int(b.length)))
+	case *[]Rowid:
+		*x = (*x)[:0]
+		for i := range data {
+			if data[i].isNull == 1 {
+				*x = append(*x, Rowid{})
+				continue
+			}
+			b := C.dpiData_getBytes(&data[i])
+			*x = append(*x, NewRowid(C.GoStringN(b.ptr, C.int(b.length))))
+		}
+
+	case *XML:
+		if len(data) == 0 || data[0].isNull == 1 {
+			*x = ""
+			return nil
+		}
+		b := C.dpiData_getBytes(&data[0])
+		*x = XML(((*[32767]byte)(unsafe.Pointer(b.ptr)))[:b.length:b.length])
+	case *[]XML:
+		*x = (*x)[:0]
+		for i := range data {
+			if data[i].isNull == 1 {
+				*x = append(*x, "")
+				continue
+			}
+			b := C.dpiData_getBytes(&data[i])
+			*x = append(*x, XML(((*[32767]byte)(unsafe.Pointer(b.ptr)))[:b.length:b.length]))
+		}
+
+	case *NString:
+		if len(data) == 0 || data[0].isNull == 1 {
+			*x = ""
+			return nil
+		}
+		b := C.dpiData_getBytes(&data[0])
+		*x = NString(((*[32767]byte)(unsafe.Pointer(b.ptr)))[:b.length:b.length])
+	case *[]NString:
+		*x = (*x)[:0]
+		for i := range data {
+			if data[i].isNull == 1 {
+				*x = append(*x, "")
+				continue
+			}
+			b := C.dpiData_getBytes(&data[i])
+			*x = append(*x, NString(((*[32767]byte)(unsafe.Pointer(b.ptr)))[:b.length:b.length]))
+		}
+
 	case *string:
 		if len(data) == 0 || data[0].isNull == 1 {
 			*x = ""
@@ -1695,6 +2650,60 @@ func dataSetBytes(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
 			dpiSetFromString(dv, C.uint32_t(i), string(x))
 		}
 
+	case Rowid:
+		i, x := 0, slice
+		if len(x.s) == 0 {
+			data[i].isNull = 1
+			return nil
+		}
+		data[i].isNull = 0
+		dpiSetFromString(dv, C.uint32_t(i), x.s)
+	case []Rowid:
+		for i, x := range slice {
+			if len(x.s) == 0 {
+				data[i].isNull = 1
+				continue
+			}
+			data[i].isNull = 0
+			dpiSetFromString(dv, C.uint32_t(i), x.s)
+		}
+
+	case XML:
+		i, x := 0, slice
+		if len(x) == 0 {
+			data[i].isNull = 1
+			return nil
+		}
+		data[i].isNull = 0
+		dpiSetFromString(dv, C.uint32_t(i), string(x))
+	case []XML:
+		for i, x := range slice {
+			if len(x) == 0 {
+				data[i].isNull = 1
+				continue
+			}
+			data[i].isNull = 0
+			dpiSetFromString(dv, C.uint32_t(i), string(x))
+		}
+
+	case NString:
+		i, x := 0, slice
+		if len(x) == 0 {
+			data[i].isNull = 1
+			return nil
+		}
+		data[i].isNull = 0
+		dpiSetFromString(dv, C.uint32_t(i), string(x))
+	case []NString:
+		for i, x := range slice {
+			if len(x) == 0 {
+				data[i].isNull = 1
+				continue
+			}
+			data[i].isNull = 0
+			dpiSetFromString(dv, C.uint32_t(i), string(x))
+		}
+
 	case string:
 		i, x := 0, slice
 		if len(x) == 0 {
@@ -1714,7 +2723,34 @@ func dataSetBytes(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
 		}
 
 	default:
-		return errors.Errorf("awaited [][]byte/[]string/[]Number, got %T (%#v)", vv, vv)
+		return errors.Errorf("awaited [][]byte/[]string/[]NString/[]XML/[]Number/[]Rowid, got %T (%#v)", vv, vv)
+	}
+	return nil
+}
+
+func (st *statement) dataSetStmt(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
+	var r *rows
+	switch x := vv.(type) {
+	case Cursor:
+		r = x.rows
+	case *rows:
+		r = x
+	default:
+		return errors.Errorf("awaited Cursor or driver.Rows, got %T (%#v)", vv, vv)
+	}
+	if r == nil {
+		data[0].isNull = 1
+		return nil
+	}
+	if r.statement == nil || r.dpiStmt == nil {
+		return errors.New("cursor is closed")
+	}
+	if r.conn != st.conn {
+		return errors.New("cursor belongs to another connection")
+	}
+	data[0].isNull = 0
+	if C.dpiVar_setFromStmt(dv, 0, r.dpiStmt) == C.DPI_FAILURE {
+		return errors.Errorf("setFromStmt: %w", st.getError())
 	}
 	return nil
 }
@@ -1747,7 +2783,7 @@ func (c *conn) dataGetStmtC(row *driver.Rows, data *C.dpiData) error {
 		*row = nil
 		return nil
 	}
-	st := &statement{conn: c, dpiStmt: C.dpiData_getStmt(data)}
+	st := &statement{conn: c, dpiStmt: C.dpiData_getStmt(data), stmtOptions: c.stmtOptions()}
 
 	var n C.uint32_t
 	if C.dpiStmt_getNumQueryColumns(st.dpiStmt, &n) == C.DPI_FAILURE {
@@ -1791,7 +2827,7 @@ func (c *conn) dataGetLOBC(L *Lob, data *C.dpiData) {
 	if lob == nil {
 		return
 	}
-	L.Reader = &dpiLobReader{conn: c, dpiLob: lob, IsClob: L.IsClob}
+	L.Reader = &dpiLobReader{conn: c, dpiLob: lob, IsClob: L.IsClob || L.IsNClob}
 }
 
 func (c *conn) dataSetLOB(dv *C.dpiVar, data []C.dpiData, vv interface{}) error {
@@ -1822,7 +2858,9 @@ func (c *conn) dataSetLOB(dv *C.dpiVar, data []C.dpiData, vv interface{}) error
 		}
 
 		typ := C.dpiOracleTypeNum(C.DPI_ORACLE_TYPE_BLOB)
-		if L.IsClob {
+		if L.IsNClob {
+			typ = C.DPI_ORACLE_TYPE_NCLOB
+		} else if L.IsClob {
 			typ = C.DPI_ORACLE_TYPE_CLOB
 		}
 		var lob *C.dpiLob
@@ -1837,7 +2875,7 @@ func (c *conn) dataSetLOB(dv *C.dpiVar, data []C.dpiData, vv interface{}) error
 		for chunkSize < minChunkSize {
 			chunkSize <<= 1
 		}
-		lw := &dpiLobWriter{dpiLob: lob, conn: c, isClob: L.IsClob}
+		lw := &dpiLobWriter{dpiLob: lob, conn: c, isClob: L.IsClob || L.IsNClob}
 		_, err := io.CopyBuffer(lw, L, make([]byte, int(chunkSize)))
 		//fmt.Printf("%p written %d with chunkSize=%d\n", lob, n, chunkSize)
 		if closeErr := lw.Close(); closeErr != nil {
@@ -2001,6 +3039,7 @@ func (st *statement) openRows(colCount int) (*rows, error) {
 		columns:   make([]Column, colCount),
 		vars:      make([]*C.dpiVar, colCount),
 		data:      make([][]C.dpiData, colCount),
+		rowids:    decodesRowids(st.decodeTypes),
 	}
 	sliceLen := st.FetchRowCount()
 
@@ -2011,13 +3050,28 @@ func (st *statement) openRows(colCount int) (*rows, error) {
 			return nil, errors.Errorf("getQueryInfo[%d]: %w", i, st.getError())
 		}
 		ti = info.typeInfo
+		if len(st.decodeTypes) != 0 {
+			if tc := columnDecoder(st.decodeTypes, ti.oracleTypeNum); tc != nil {
+				if r.decoders == nil {
+					r.decoders = make([]*typeConv, colCount)
+				}
+				r.decoders[i] = tc
+			}
+		}
 		bufSize := int(ti.clientSizeInBytes)
+		// varTyp is the type of the define variable, if differs from the column's
+		var varTyp C.dpiOracleTypeNum
 		//if Log != nil {Log("msg", "openRows", "col", i, "info", ti) }
 		//if Log != nil {Log("dNTN", int(ti.defaultNativeTypeNum), "number", C.DPI_ORACLE_TYPE_NUMBER) }
 		switch ti.oracleTypeNum {
 		case C.DPI_ORACLE_TYPE_NUMBER:
 			switch ti.defaultNativeTypeNum {
 			case C.DPI_NATIVE_TYPE_FLOAT, C.DPI_NATIVE_TYPE_DOUBLE:
+				// FLOAT(b) is approximate anyway
+				if st.NativeNumbers() && ti.precision > 0 && ti.scale == -127 {
+					ti.defaultNativeTypeNum = C.DPI_NATIVE_TYPE_DOUBLE
+					break
+				}
 				ti.defaultNativeTypeNum = C.DPI_NATIVE_TYPE_BYTES
 				bufSize = 40
 			}
@@ -2034,6 +3088,12 @@ func (st *statement) openRows(colCount int) (*rows, error) {
 				ti.oracleTypeNum = C.DPI_ORACLE_TYPE_LONG_VARCHAR
 				ti.defaultNativeTypeNum = C.DPI_NATIVE_TYPE_BYTES
 			}
+		case C.DPI_ORACLE_TYPE_TIMESTAMP_TZ:
+			// fetched as text, with the region name (see TimeZoneRegions)
+			if st.TimeZoneRegions() {
+				varTyp, ti.defaultNativeTypeNum = C.DPI_ORACLE_TYPE_VARCHAR, C.DPI_NATIVE_TYPE_BYTES
+				bufSize = tzRegionBufSize
+			}
 		}
 		r.columns[i] = Column{
 			Name:        C.GoStringN(info.name, C.int(info.nameLength)),
@@ -2046,6 +3106,8 @@ func (st *statement) openRows(colCount int) (*rows, error) {
 			ObjectType:  ti.objectType,
 			SizeInChars: ti.sizeInChars,
 			DBSize:      ti.dbSizeInBytes,
+			// a real LONG column has the SQLT_LNG type code
+			isXML: ti.oracleTypeNum == C.DPI_ORACLE_TYPE_LONG_VARCHAR && ti.ociTypeCode == C.DPI_SQLT_CHR,
 		}
 		var err error
 		//fmt.Printf("%d. %+v\n", i, r.columns[i])
@@ -2056,6 +3118,9 @@ func (st *statement) openRows(colCount int) (*rows, error) {
 			BufSize:    bufSize,
 			SliceLen:   sliceLen,
 		}
+		if varTyp != 0 {
+			vi.Typ = varTyp
+		}
 		if r.vars[i], r.data[i], err = st.newVar(vi); err != nil {
 			return nil, err
 		}
@@ -2081,4 +3146,6 @@ type Column struct {
 	Precision                 C.int16_t
 	Scale                     C.int8_t
 	Nullable                  bool
+	// isXML is true for XMLTYPE, which ODPI-C describes as LONG_VARCHAR.
+	isXML bool
 }
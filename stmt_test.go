@@ -0,0 +1,184 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"database/sql/driver"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	errors "golang.org/x/xerrors"
+)
+
+// foreignRows is a driver.Rows of another driver.
+type foreignRows struct{}
+
+func (foreignRows) Columns() []string         { return nil }
+func (foreignRows) Close() error              { return nil }
+func (foreignRows) Next([]driver.Value) error { return io.EOF }
+
+func TestRowCounts(t *testing.T) {
+	var st statement
+	if st.resetRowCounts() {
+		t.Error("row counts without the option")
+	}
+	counts := []int64{1}
+	nv := driver.NamedValue{Ordinal: 1, Value: ArrayDMLRowCounts(&counts)}
+	if err := st.CheckNamedValue(&nv); err != driver.ErrRemoveArgument {
+		t.Fatalf("CheckNamedValue: got %v, wanted ErrRemoveArgument", err)
+	}
+	if st.resetRowCounts() {
+		t.Error("row counts without array DML")
+	}
+	if counts != nil {
+		t.Errorf("got %v, wanted the counts reset", counts)
+	}
+
+	st.arrLen = 3
+	if !st.resetRowCounts() {
+		t.Fatal("no row counts for array DML")
+	}
+	st.setRowCounts([]uint64{1, 0, 1 << 40})
+	if want := []int64{1, 0, 1 << 40}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("got %v, wanted %v", counts, want)
+	}
+
+	PlSQLArrays(&st.stmtOptions)
+	if st.resetRowCounts() || counts != nil {
+		t.Errorf("got %v for PL/SQL arrays", counts)
+	}
+}
+
+func TestBatchErrorString(t *testing.T) {
+	be := &BatchError{RowsAffected: 2, Errors: []RowError{
+		{Offset: 1, Err: &OraErr{code: 1, message: "unique constraint violated"}},
+		{Offset: 65535, Err: &OraErr{code: 1438, message: "value larger than specified precision"}},
+	}}
+	s := be.Error()
+	t.Log(s)
+	if !strings.HasPrefix(s, "2 rows failed (2 succeeded): 1: ORA-00001") || !strings.Contains(s, "65535: ORA-01438") {
+		t.Errorf("got %q", s)
+	}
+	if oe, ok := AsOraErr(be); !ok || oe.Code() != 1 {
+		t.Errorf("got %v, wanted the first row's error", oe)
+	}
+}
+
+func TestCheckBatchErrorsRows(t *testing.T) {
+	for _, tc := range []struct {
+		N       int
+		WantErr bool
+	}{
+		{N: 1}, {N: 65535}, {N: 65536, WantErr: true}, {N: 100000, WantErr: true},
+	} {
+		err := checkBatchErrorsRows(tc.N)
+		if (err != nil) != tc.WantErr {
+			t.Errorf("%d: got %v, wanted error=%t", tc.N, err, tc.WantErr)
+		} else if err != nil && !errors.Is(err, ErrNotSupported) {
+			t.Errorf("%d: got %v, wanted ErrNotSupported", tc.N, err)
+		}
+	}
+}
+
+func TestReturnSliceType(t *testing.T) {
+	var ints []int
+	var perIter [][]string
+	var raws [][]byte
+	var rawsPerIter [][][]byte
+	for i, tc := range []struct {
+		Dest interface{}
+		Want reflect.Type
+	}{
+		{Dest: &ints},
+		{Dest: &raws},
+		{Dest: &perIter, Want: reflect.TypeOf([]string(nil))},
+		{Dest: &rawsPerIter, Want: reflect.TypeOf([][]byte(nil))},
+		{Dest: &ReturnedRows{Dest: &ints}, Want: reflect.TypeOf([]int(nil))},
+		{Dest: &ReturnedRows{Dest: ints}},
+	} {
+		if got := returnSliceType(tc.Dest); got != tc.Want {
+			t.Errorf("%d. %T: got %v, wanted %v", i, tc.Dest, got, tc.Want)
+		}
+	}
+}
+
+func TestAsCursor(t *testing.T) {
+	r := &rows{}
+	if cur, err := AsCursor(r); err != nil || cur.rows != r {
+		t.Errorf("got %v (%+v), wanted the rows", cur, err)
+	}
+	if _, err := AsCursor(foreignRows{}); err == nil {
+		t.Error("wanted error for a foreign type")
+	}
+}
+
+func TestScrollableRowsEOF(t *testing.T) {
+	for _, scrollable := range []bool{true, false} {
+		st := &statement{isScrollable: scrollable}
+		r := &rows{statement: st, finished: true}
+		if err := r.Next(nil); err != io.EOF {
+			t.Errorf("scrollable=%t: got %v, wanted EOF", scrollable, err)
+		}
+		if isOpen := r.statement != nil; isOpen != scrollable {
+			t.Errorf("scrollable=%t: open after EOF: %t", scrollable, isOpen)
+		}
+	}
+}
+
+func TestOracleType(t *testing.T) {
+	for typ := TypeVarchar; typ <= TypeTimestampLTZ; typ++ {
+		if _, err := typ.oracleTypeNum(); err != nil {
+			t.Errorf("%d: %+v", typ, err)
+		}
+	}
+	for _, typ := range []OracleType{0, TypeTimestampLTZ + 1} {
+		if _, err := typ.oracleTypeNum(); err == nil {
+			t.Errorf("%d: wanted error", typ)
+		}
+	}
+	if tv := As("a", TypeClob); tv.Value != "a" || tv.Type != TypeClob || tv.Size != 0 {
+		t.Errorf("got %+v", tv)
+	}
+}
+
+func TestOracleTypeInValue(t *testing.T) {
+	if L, ok := TypeClob.inValue("abc").(Lob); !ok || !L.IsClob || L.IsNClob {
+		t.Errorf("string as CLOB: got %#v", L)
+	} else if b, err := ioutil.ReadAll(L); err != nil || string(b) != "abc" {
+		t.Errorf("got %q (%+v)", b, err)
+	}
+	if L, ok := TypeNClob.inValue([]byte("a")).(Lob); !ok || !L.IsNClob {
+		t.Errorf("[]byte as NCLOB: got %#v", L)
+	}
+	if lobs, ok := TypeBlob.inValue([][]byte{[]byte("a"), nil}).([]Lob); !ok || len(lobs) != 2 || lobs[0].Reader == nil || lobs[1].Reader != nil {
+		t.Errorf("[][]byte as BLOB: got %#v", lobs)
+	}
+	if L, ok := TypeClob.inValue((*string)(nil)).(Lob); !ok || L.Reader != nil {
+		t.Errorf("nil *string as CLOB: got %#v", L)
+	}
+	if f, ok := TypeBinaryFloat.inValue(1.5).(float32); !ok || f != 1.5 {
+		t.Errorf("float64 as BINARY_FLOAT: got %#v", f)
+	}
+	if fs, ok := TypeBinaryFloat.inValue([]float64{1, 2}).([]float32); !ok || len(fs) != 2 {
+		t.Errorf("[]float64 as BINARY_FLOAT: got %#v", fs)
+	}
+	if v := TypeNumber.inValue("1"); v != "1" {
+		t.Errorf("string as NUMBER: got %#v", v)
+	}
+}
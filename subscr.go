@@ -125,6 +125,7 @@ type TableEvent struct {
 
 // RowEvent is for row-related event.
 type RowEvent struct {
+	// Rowid of the changed row, decode it with NewRowid(ev.Rowid).Decode().
 	Rowid string
 	Operation
 }
@@ -0,0 +1,321 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+/*
+#include <stdlib.h>
+#include "dpiImpl.h"
+*/
+import "C"
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unsafe"
+
+	errors "golang.org/x/xerrors"
+)
+
+// ODPI-C transfers TIMESTAMP WITH TIME ZONE values with the offset only,
+// so for the TimeZoneRegions option they are converted to/from text in this format
+// (set as NLS_TIMESTAMP_TZ_FORMAT of the session, till the connection is closed).
+// The daylight saving abbreviation (TZD) tells apart the ambiguous times at the end of the summer time.
+const (
+	tzRegionNLSFormat = `YYYY-MM-DD"T"HH24:MI:SS.FF9 TZR TZD`
+	tzRegionLayout    = "2006-01-02T15:04:05.999999999"
+	// tzRegionBufSize is enough for the timestamp and the longest region name.
+	tzRegionBufSize = 80
+)
+
+var tzRegions = struct {
+	sync.RWMutex
+	// locations caches the loaded locations, by the name returned by the database.
+	locations map[string]*time.Location
+	// names maps the upper case region names to the names in V$TIMEZONE_NAMES,
+	// as the database returns them in upper case.
+	names map[string]string
+}{locations: make(map[string]*time.Location)}
+
+// initTZRegions sets the NLS_TIMESTAMP_TZ_FORMAT of the session for the TimeZoneRegions option,
+// and loads the region names from the database, if not yet loaded.
+//
+// The original format is restored by resetTZRegions when the connection is closed.
+func (c *conn) initTZRegions(ctx context.Context) error {
+	if c.tzRegions {
+		return nil
+	}
+	const formatQry = "SELECT value FROM nls_session_parameters WHERE parameter = 'NLS_TIMESTAMP_TZ_FORMAT'"
+	formats, err := c.queryStrings(ctx, formatQry)
+	if err != nil {
+		return errors.Errorf("%s: %w", formatQry, err)
+	}
+	if len(formats) == 0 {
+		return errors.Errorf("%s: no rows", formatQry)
+	}
+	qry := nlsTimestampTZFormat(tzRegionNLSFormat)
+	if err = c.execInternal(ctx, qry); err != nil {
+		return errors.Errorf("%s: %w", qry, err)
+	}
+	c.tzRegions, c.tzFormat = true, formats[0]
+
+	tzRegions.RLock()
+	loaded := tzRegions.names != nil
+	tzRegions.RUnlock()
+	if loaded {
+		return nil
+	}
+	const namesQry = "SELECT DISTINCT tzname FROM v$timezone_names"
+	names, err := c.queryNames(ctx, namesQry)
+	if err != nil {
+		// not fatal, the properly cased names can be loaded without it
+		if Log != nil {
+			Log("qry", namesQry, "error", err)
+		}
+		return nil
+	}
+	tzRegions.Lock()
+	tzRegions.names = names
+	tzRegions.Unlock()
+	return nil
+}
+
+func (c *conn) execInternal(ctx context.Context, qry string) error {
+	st, err := c.PrepareContext(ctx, qry)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	_, err = st.(*statement).ExecContext(ctx, nil)
+	return err
+}
+
+// resetTZRegions restores the NLS_TIMESTAMP_TZ_FORMAT of the session changed by initTZRegions,
+// before the session is released to the pool.
+//
+// It is called by close, with the conn locked, so it uses the dpiConn directly.
+func (c *conn) resetTZRegions() error {
+	qry := nlsTimestampTZFormat(c.tzFormat)
+	cSQL := C.CString(qry)
+	defer C.free(unsafe.Pointer(cSQL))
+	var dpiStmt *C.dpiStmt
+	if C.dpiConn_prepareStmt(c.dpiConn, 0, cSQL, C.uint32_t(len(qry)), nil, 0, &dpiStmt) == C.DPI_FAILURE {
+		return errors.Errorf("%s: %w", qry, c.getError())
+	}
+	defer C.dpiStmt_release(dpiStmt)
+	var colCount C.uint32_t
+	if C.dpiStmt_execute(dpiStmt, C.DPI_MODE_EXEC_DEFAULT, &colCount) == C.DPI_FAILURE {
+		return errors.Errorf("%s: %w", qry, c.getError())
+	}
+	c.tzRegions = false
+	return nil
+}
+
+func nlsTimestampTZFormat(format string) string {
+	return "ALTER SESSION SET NLS_TIMESTAMP_TZ_FORMAT = '" + strings.Replace(format, "'", "''", -1) + "'"
+}
+
+// queryNames returns the upper case - original name map of the first column of the query.
+func (c *conn) queryNames(ctx context.Context, qry string) (map[string]string, error) {
+	ss, err := c.queryStrings(ctx, qry)
+	names := make(map[string]string, len(ss))
+	for _, s := range ss {
+		names[strings.ToUpper(s)] = s
+	}
+	return names, err
+}
+
+// queryStrings returns the (non-NULL) values of the first column of the query.
+func (c *conn) queryStrings(ctx context.Context, qry string) ([]string, error) {
+	st, err := c.PrepareContext(ctx, qry)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	rows, err := st.(*statement).QueryContext(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ss []string
+	vals := []driver.Value{nil}
+	for {
+		if err = rows.Next(vals); err != nil {
+			if err == io.EOF {
+				return ss, nil
+			}
+			return ss, err
+		}
+		if s, ok := vals[0].(string); ok {
+			ss = append(ss, s)
+		}
+	}
+}
+
+// loadTZRegion returns the location of the region name, as returned by the database.
+func loadTZRegion(name string) (*time.Location, error) {
+	tzRegions.RLock()
+	loc := tzRegions.locations[name]
+	proper := tzRegions.names[strings.ToUpper(name)]
+	tzRegions.RUnlock()
+	if loc != nil {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil && proper != "" && proper != name {
+		loc, err = time.LoadLocation(proper)
+	}
+	if err != nil {
+		return nil, errors.Errorf("load time zone region %q: %w", name, err)
+	}
+	tzRegions.Lock()
+	tzRegions.locations[name] = loc
+	tzRegions.Unlock()
+	return loc, nil
+}
+
+// tzRegionOf returns the region name of the location, if it has one known by Go.
+func tzRegionOf(loc *time.Location) (string, bool) {
+	if loc == nil || loc == time.Local || loc == time.UTC {
+		return "", false
+	}
+	name := loc.String()
+	if name == "" || name == "Local" || name == "UTC" {
+		return "", false
+	}
+	if _, err := loadTZRegion(name); err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// formatTZRegion formats the time with its region name, in tzRegionNLSFormat.
+// The abbreviation is added only if it is a name (not a numeric offset), as TZD is.
+func formatTZRegion(t time.Time, region string) string {
+	s := t.Format(tzRegionLayout) + " " + region
+	if abbr, _ := t.Zone(); isTZAbbr(abbr) {
+		s += " " + abbr
+	}
+	return s
+}
+
+func isTZAbbr(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// tzRegionStrings returns the time.Time or []time.Time as string or []string
+// in tzRegionNLSFormat, if all the (non-zero) times are in a region.
+func tzRegionStrings(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		if x.IsZero() {
+			return nil, false
+		}
+		region, ok := tzRegionOf(x.Location())
+		if !ok {
+			return nil, false
+		}
+		return formatTZRegion(x, region), true
+	case []time.Time:
+		ss := make([]string, len(x))
+		var hasTime bool
+		for i, t := range x {
+			if t.IsZero() {
+				continue
+			}
+			region, ok := tzRegionOf(t.Location())
+			if !ok {
+				return nil, false
+			}
+			ss[i], hasTime = formatTZRegion(t, region), true
+		}
+		return ss, hasTime
+	}
+	return nil, false
+}
+
+// parseTZRegion parses the text returned in tzRegionNLSFormat.
+func parseTZRegion(s string) (time.Time, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 3 {
+		return time.Time{}, errors.Errorf("%q: no time zone", s)
+	}
+	ts, zone := fields[0], fields[1]
+	var abbr string
+	if len(fields) == 3 {
+		abbr = fields[2]
+	}
+	var loc *time.Location
+	if zone != "" && (zone[0] == '+' || zone[0] == '-') {
+		off, err := parseTZOffset(zone)
+		if err != nil {
+			return time.Time{}, errors.Errorf("%q: %w", s, err)
+		}
+		loc = timeZoneFor(C.int8_t(off/60), C.int8_t(off%60))
+	} else {
+		var err error
+		if loc, err = loadTZRegion(zone); err != nil {
+			return time.Time{}, err
+		}
+	}
+	t, err := time.ParseInLocation(tzRegionLayout, ts, loc)
+	if err != nil || abbr == "" {
+		return t, err
+	}
+	// the wall clock is ambiguous at the end of the summer time: choose the one with the abbreviation
+	if a, _ := t.Zone(); a != abbr {
+		for _, d := range []time.Duration{-time.Hour, time.Hour} {
+			if t2 := t.Add(d); t2.Format(tzRegionLayout) == t.Format(tzRegionLayout) {
+				if a, _ := t2.Zone(); a == abbr {
+					return t2, nil
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// parseTZOffset parses the [+-]HH:MI offset into minutes.
+func parseTZOffset(s string) (int, error) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return 0, errors.Errorf("%q: not an offset", s)
+	}
+	h, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return 0, err
+	}
+	if s[0] == '-' {
+		m = -m
+	}
+	return h*60 + m, nil
+}
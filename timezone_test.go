@@ -0,0 +1,105 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTZRegion(t *testing.T) {
+	budapest, err := time.LoadLocation("Europe/Budapest")
+	if err != nil {
+		t.Skip(err)
+	}
+	summer := time.Date(2019, 7, 1, 12, 30, 0, 123456789, budapest)
+	winter := time.Date(2019, 12, 1, 12, 30, 0, 0, budapest)
+
+	s, ok := tzRegionStrings(summer)
+	if !ok || s != "2019-07-01T12:30:00.123456789 Europe/Budapest CEST" {
+		t.Errorf("got %q (%t)", s, ok)
+	}
+	got, err := parseTZRegion(s.(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(summer) || got.Location().String() != "Europe/Budapest" {
+		t.Errorf("got %v, wanted %v", got, summer)
+	}
+	if _, off := got.Zone(); off != 2*3600 {
+		t.Errorf("summer offset: got %d", off)
+	}
+
+	if ss, ok := tzRegionStrings([]time.Time{winter, {}}); !ok || !reflect.DeepEqual(ss, []string{"2019-12-01T12:30:00 Europe/Budapest CET", ""}) {
+		t.Errorf("got %q (%t)", ss, ok)
+	}
+	for _, v := range []interface{}{
+		time.Time{}, summer.UTC(), summer.Local(), summer.In(time.FixedZone("+02:00", 7200)),
+		[]time.Time{summer, summer.UTC()}, []time.Time{{}},
+	} {
+		if s, ok := tzRegionStrings(v); ok {
+			t.Errorf("%v: wanted no region, got %q", v, s)
+		}
+	}
+
+	for s, want := range map[string]time.Time{
+		"2019-07-01T12:30:00.000000000 +02:00": time.Date(2019, 7, 1, 10, 30, 0, 0, time.UTC),
+		"2019-07-01T12:30:00.000000000 -03:30": time.Date(2019, 7, 1, 16, 0, 0, 0, time.UTC),
+		"2019-07-01T12:30:00.000000000 UTC":    time.Date(2019, 7, 1, 12, 30, 0, 0, time.UTC),
+		// the end of the summer time: 02:30 is twice
+		"2019-10-27T02:30:00.000000000 Europe/Budapest CEST": time.Date(2019, 10, 27, 0, 30, 0, 0, time.UTC),
+		"2019-10-27T02:30:00.000000000 Europe/Budapest CET":  time.Date(2019, 10, 27, 1, 30, 0, 0, time.UTC),
+		"2019-07-01T12:30:00.000000000 +02:00 ":              time.Date(2019, 7, 1, 10, 30, 0, 0, time.UTC),
+	} {
+		if got, err := parseTZRegion(s); err != nil || !got.Equal(want) {
+			t.Errorf("%q: got %v (%v), wanted %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"2019-07-01T12:30:00", "2019-07-01T12:30:00 Nowhere/Special", "2019-07-01T12:30:00 +2",
+		"2019-07-01T12:30:00 Europe/Budapest CEST x"} {
+		if got, err := parseTZRegion(s); err == nil {
+			t.Errorf("%q: wanted error, got %v", s, got)
+		}
+	}
+
+	// the database returns the region names in upper case
+	tzRegions.Lock()
+	names := tzRegions.names
+	tzRegions.names = map[string]string{"EUROPE/BUDAPEST": "Europe/Budapest"}
+	tzRegions.Unlock()
+	defer func() {
+		tzRegions.Lock()
+		tzRegions.names = names
+		tzRegions.Unlock()
+	}()
+	if got, err := parseTZRegion("2019-07-01T12:30:00.123456789 EUROPE/BUDAPEST"); err != nil || !got.Equal(summer) {
+		t.Errorf("got %v (%v), wanted %v", got, err, summer)
+	}
+}
+
+func TestContextWithTimeZone(t *testing.T) {
+	tz := time.FixedZone("X", 3600)
+	st := &statement{conn: &conn{timeZone: time.UTC}}
+	if got := st.location(); got != time.UTC {
+		t.Errorf("got %v, wanted the connection's", got)
+	}
+	st.tzOverride, _ = ContextWithTimeZone(context.Background(), tz).Value(timeZoneCtxKey).(*time.Location)
+	if got := st.location(); got != tz {
+		t.Errorf("got %v, wanted %v", got, tz)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+/*
+#include "dpiImpl.h"
+*/
+import "C"
+import (
+	"reflect"
+	"sync"
+
+	errors "golang.org/x/xerrors"
+)
+
+// typeConv is a registered conversion, see RegisterType.
+type typeConv struct {
+	goType, baseType reflect.Type
+	typ              C.dpiOracleTypeNum
+	encode, decode   func(interface{}) (interface{}, error)
+}
+
+var typeConvs = struct {
+	sync.RWMutex
+	byGoType map[reflect.Type]*typeConv
+}{
+	byGoType: make(map[reflect.Type]*typeConv),
+}
+
+// RegisterType registers the conversion of the Go type of goType (a sample value, e.g. UUID{})
+// to and from the Oracle type typ.
+//
+// encode converts a goType value into a value which can be bound as typ
+// ([]byte for TypeRaw, Number or string for TypeNumber, string for TypeVarchar ...),
+// or into nil for NULL. It must succeed for the zero value of goType,
+// as that determines the bound Go type.
+//
+// decode converts the fetched value (of the bound Go type for OUT parameters,
+// and of the default type for the column, such as Number or []byte, for query results)
+// into a goType value. NULLs are not decoded.
+// The columns of typ are returned as goType only for the queries with the DecodeTypes(goType) option;
+// without decode, goType can be bound only as IN parameter.
+//
+// The registered types can be bound as IN and OUT parameters, in slices for array DML,
+// and in PL/SQL arrays.
+func RegisterType(goType interface{}, typ OracleType, encode, decode func(interface{}) (interface{}, error)) error {
+	if goType == nil || encode == nil {
+		return errors.New("goType and encode must not be nil")
+	}
+	oTyp, err := typ.oracleTypeNum()
+	if err != nil {
+		return err
+	}
+	tc := typeConv{goType: reflect.TypeOf(goType), typ: oTyp, encode: encode, decode: decode}
+	zero, err := encode(reflect.Zero(tc.goType).Interface())
+	if err != nil {
+		return errors.Errorf("encode zero %v: %w", tc.goType, err)
+	}
+	if zero == nil {
+		return errors.Errorf("encode zero %v: got nil", tc.goType)
+	}
+	if tc.baseType = reflect.TypeOf(zero); tc.baseType == tc.goType {
+		return errors.Errorf("%v is encoded as itself", tc.goType)
+	}
+
+	typeConvs.Lock()
+	typeConvs.byGoType[tc.goType] = &tc
+	typeConvs.Unlock()
+	return nil
+}
+
+// UnregisterType removes the registration of goType's type.
+func UnregisterType(goType interface{}) {
+	typ := reflect.TypeOf(goType)
+	typeConvs.Lock()
+	delete(typeConvs.byGoType, typ)
+	typeConvs.Unlock()
+}
+
+func typeConvFor(typ reflect.Type) *typeConv {
+	typeConvs.RLock()
+	tc := typeConvs.byGoType[typ]
+	typeConvs.RUnlock()
+	return tc
+}
+
+// columnDecoder returns the registered conversion of the goTypes (see DecodeTypes)
+// for the column of the Oracle type typ, or nil.
+func columnDecoder(goTypes []reflect.Type, typ C.dpiOracleTypeNum) *typeConv {
+	typeConvs.RLock()
+	defer typeConvs.RUnlock()
+	for _, t := range goTypes {
+		if tc := typeConvs.byGoType[t]; tc != nil && tc.decode != nil && tc.typ == typ {
+			return tc
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes the fetched value into a goType value.
+func (tc *typeConv) decodeValue(v interface{}) (reflect.Value, error) {
+	d, err := tc.decode(v)
+	if err != nil {
+		return reflect.Value{}, errors.Errorf("decode %T to %v: %w", v, tc.goType, err)
+	}
+	rv := reflect.ValueOf(d)
+	if !rv.IsValid() {
+		return reflect.Zero(tc.goType), nil
+	}
+	if rv.Type() != tc.goType {
+		if !rv.Type().ConvertibleTo(tc.goType) {
+			return rv, errors.Errorf("decode %T: got %T, wanted %v", v, d, tc.goType)
+		}
+		rv = rv.Convert(tc.goType)
+	}
+	return rv, nil
+}
+
+// encodeValue encodes the goType value into a baseType value, and whether it is NULL.
+func (tc *typeConv) encodeValue(v interface{}) (reflect.Value, bool, error) {
+	e, err := tc.encode(v)
+	if err != nil {
+		return reflect.Value{}, false, errors.Errorf("encode %v: %w", tc.goType, err)
+	}
+	if e == nil {
+		return reflect.Zero(tc.baseType), true, nil
+	}
+	rv := reflect.ValueOf(e)
+	if rv.Type() != tc.baseType {
+		return rv, false, errors.Errorf("encode %v: got %T, wanted %v", tc.goType, e, tc.baseType)
+	}
+	return rv, false, nil
+}
+
+// registered is for binding values of registered types (T, []T):
+// it returns the encoded base (B or []B) value, which is to be bound instead,
+// whether the elements are NULL,
+// and the wrapper for the base type's dataGetter, for the OUT direction.
+//
+// For OUT parameters, value is the pointer to the destination,
+// and base is a pointer, too.
+func registered(value interface{}, isOut bool) (base interface{}, tc *typeConv, nulls []bool, wrapGet func(dataGetter) dataGetter, err error) {
+	rv := reflect.ValueOf(value)
+	if isOut && rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	} else if isOut || !rv.IsValid() {
+		return nil, nil, nil, nil, nil
+	}
+	typ := rv.Type()
+	isSlice := false
+	if tc = typeConvFor(typ); tc == nil && typ.Kind() == reflect.Slice {
+		isSlice, tc = true, typeConvFor(typ.Elem())
+	}
+	if tc == nil {
+		return nil, nil, nil, nil, nil
+	}
+	if isOut && tc.decode == nil {
+		return nil, tc, nil, nil, errors.Errorf("%v has no decode registered, cannot be used as OUT parameter", tc.goType)
+	}
+
+	if !isSlice {
+		b, isNull, err := tc.encodeValue(rv.Interface())
+		if err != nil {
+			return nil, tc, nil, nil, err
+		}
+		base, nulls = b.Interface(), []bool{isNull}
+	} else {
+		n := rv.Len()
+		// keep the capacity, as that is the size of the OUT PL/SQL arrays
+		bs := reflect.MakeSlice(reflect.SliceOf(tc.baseType), n, rv.Cap())
+		nulls = make([]bool, n)
+		for i := 0; i < n; i++ {
+			b, isNull, err := tc.encodeValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, tc, nil, nil, errors.Errorf("%d. element: %w", i, err)
+			}
+			bs.Index(i).Set(b)
+			nulls[i] = isNull
+		}
+		base = bs.Interface()
+	}
+	if isOut {
+		p := reflect.New(reflect.TypeOf(base))
+		p.Elem().Set(reflect.ValueOf(base))
+		base = p.Interface()
+	}
+
+	wrapGet = func(get dataGetter) dataGetter {
+		return func(v interface{}, data []C.dpiData) error {
+			dst := reflect.ValueOf(v).Elem()
+			if !isSlice {
+				tmp := reflect.New(tc.baseType)
+				if err := get(tmp.Interface(), data); err != nil {
+					return err
+				}
+				if len(data) == 0 || data[0].isNull == 1 {
+					dst.Set(reflect.Zero(tc.goType))
+					return nil
+				}
+				d, err := tc.decodeValue(tmp.Elem().Interface())
+				if err != nil {
+					return err
+				}
+				dst.Set(d)
+				return nil
+			}
+			tmp := reflect.New(reflect.SliceOf(tc.baseType))
+			if err := get(tmp.Interface(), data); err != nil {
+				return err
+			}
+			bs := tmp.Elem()
+			n := bs.Len()
+			out := reflect.MakeSlice(typ, n, n)
+			for i := 0; i < n; i++ {
+				if i < len(data) && data[i].isNull == 1 {
+					continue
+				}
+				d, err := tc.decodeValue(bs.Index(i).Interface())
+				if err != nil {
+					return errors.Errorf("%d. element: %w", i, err)
+				}
+				out.Index(i).Set(d)
+			}
+			dst.Set(out)
+			return nil
+		}
+	}
+	return base, tc, nulls, wrapGet, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	errors "golang.org/x/xerrors"
+)
+
+type testColor uint8
+
+func TestTypeConv(t *testing.T) {
+	colors := []string{"", "red", "green"}
+	encode := func(v interface{}) (interface{}, error) {
+		c := v.(testColor)
+		if c == 0 {
+			return nil, nil
+		}
+		if int(c) >= len(colors) {
+			return nil, errors.Errorf("unknown color %d", c)
+		}
+		return colors[c], nil
+	}
+	decode := func(v interface{}) (interface{}, error) {
+		for i, s := range colors {
+			if s == v.(string) {
+				return testColor(i), nil
+			}
+		}
+		return nil, errors.Errorf("unknown color %q", v)
+	}
+	if err := RegisterType(testColor(0), TypeVarchar, encode, nil); err == nil {
+		t.Error("wanted error for nil encoding of the zero value")
+	}
+	encodeZero := func(v interface{}) (interface{}, error) {
+		if v.(testColor) == 0 {
+			return "", nil
+		}
+		return encode(v)
+	}
+	if err := RegisterType(testColor(0), 0, encodeZero, decode); err == nil {
+		t.Error("wanted error for invalid OracleType")
+	}
+	if err := RegisterType(testColor(0), TypeVarchar, encodeZero, decode); err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterType(testColor(0))
+	vc, _ := TypeVarchar.oracleTypeNum()
+	raw, _ := TypeRaw.oracleTypeNum()
+	if tc := columnDecoder(nil, vc); tc != nil {
+		t.Errorf("column is decoded without DecodeTypes: %+v", tc)
+	}
+	var o stmtOptions
+	DecodeTypes(testColor(0))(&o)
+	if tc := columnDecoder(o.decodeTypes, raw); tc != nil {
+		t.Errorf("RAW column is decoded: %+v", tc)
+	}
+	if tc := columnDecoder(o.decodeTypes, vc); tc == nil || tc.goType != reflect.TypeOf(testColor(0)) {
+		t.Errorf("column conversion is not registered: %+v", tc)
+	}
+
+	for k, tc := range []struct {
+		Value interface{}
+		Base  interface{}
+		Nulls []bool
+	}{
+		{Value: testColor(1), Base: "red", Nulls: []bool{false}},
+		{Value: []testColor{2, 0, 1}, Base: []string{"green", "", "red"}, Nulls: []bool{false, false, false}},
+	} {
+		base, conv, nulls, wrapGet, err := registered(tc.Value, false)
+		if err != nil || conv == nil || wrapGet == nil {
+			t.Errorf("%d. %T is not registered: %+v", k, tc.Value, err)
+			continue
+		}
+		if !reflect.DeepEqual(base, tc.Base) || !reflect.DeepEqual(nulls, tc.Nulls) {
+			t.Errorf("%d. got %#v %v, wanted %#v %v", k, base, nulls, tc.Base, tc.Nulls)
+		}
+
+		// as OUT, the destination and the base are pointers
+		dest := reflect.New(reflect.TypeOf(tc.Value))
+		dest.Elem().Set(reflect.ValueOf(tc.Value))
+		if base, _, _, _, err = registered(dest.Interface(), true); err != nil {
+			t.Errorf("%d. OUT: %+v", k, err)
+			continue
+		}
+		if got := reflect.ValueOf(base).Elem().Interface(); !reflect.DeepEqual(got, tc.Base) {
+			t.Errorf("%d. OUT got %#v, wanted %#v", k, got, tc.Base)
+		}
+	}
+
+	if _, _, _, _, err := registered([]testColor{1, 9}, false); err == nil || !strings.Contains(err.Error(), "unknown color") {
+		t.Errorf("wanted encode error, got %+v", err)
+	}
+	for _, v := range []interface{}{nil, "red", []string{"red"}, uint8(1)} {
+		if _, tc, _, _, _ := registered(v, false); tc != nil {
+			t.Errorf("%T should not be registered", v)
+		}
+	}
+	if d, err := typeConvFor(reflect.TypeOf(testColor(0))).decodeValue("green"); err != nil || d.Interface() != testColor(2) {
+		t.Errorf("decode: got %v (%+v)", d, err)
+	}
+}
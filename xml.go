@@ -0,0 +1,65 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+// maxVarcharBind is the maximum length of a string bound as VARCHAR2 (in PL/SQL).
+const maxVarcharBind = 32767
+
+// XML is the text of an XMLType value.
+//
+// XMLTYPE columns are returned as XML (ODPI-C fetches them as text).
+//
+// An XML argument is bound as a string (or as a temporary CLOB, if it is longer than 32767 bytes),
+// which the database converts to XMLType in SQL. In PL/SQL there is no such implicit conversion,
+// so wrap the parameter with XMLType(:1), and use XMLType.getClobVal() for OUT parameters.
+type XML string
+
+// String returns the XML text.
+func (x XML) String() string { return string(x) }
+
+// Reader returns an io.Reader of the XML text.
+func (x XML) Reader() io.Reader { return strings.NewReader(string(x)) }
+
+// NewDecoder returns an xml.Decoder reading the XML text.
+func (x XML) NewDecoder() *xml.Decoder { return xml.NewDecoder(x.Reader()) }
+
+// Unmarshal the XML text into v, with encoding/xml.
+func (x XML) Unmarshal(v interface{}) error { return xml.Unmarshal([]byte(x), v) }
+
+// Scan implements sql.Scanner, accepting any text or []byte value.
+func (x *XML) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case nil:
+		*x = ""
+	case XML:
+		*x = s
+	case string:
+		*x = XML(s)
+	case []byte:
+		*x = XML(s)
+	default:
+		return errors.Errorf("cannot scan %T into XML", src)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestXMLType(t *testing.T) {
+	const text = `<person id="7"><name>Kovács</name></person>`
+	var x XML
+	for _, src := range []interface{}{XML(text), text, []byte(text)} {
+		x = ""
+		if err := x.Scan(src); err != nil {
+			t.Fatalf("%T: %+v", src, err)
+		}
+		if x != text {
+			t.Errorf("%T: got %q, wanted %q", src, x, text)
+		}
+	}
+	if err := x.Scan(1); err == nil {
+		t.Error("scanned int")
+	}
+	if err := x.Scan(nil); err != nil || x != "" {
+		t.Errorf("nil: got %q (%v)", x, err)
+	}
+
+	x = text
+	var p struct {
+		ID   int    `xml:"id,attr"`
+		Name string `xml:"name"`
+	}
+	if err := x.Unmarshal(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 7 || p.Name != "Kovács" {
+		t.Errorf("got %+v", p)
+	}
+	b, err := ioutil.ReadAll(x.Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != text {
+		t.Errorf("read %q", b)
+	}
+}
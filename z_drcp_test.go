@@ -0,0 +1,127 @@
+// Copyright 2019 Tamás Gulácsi
+//
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package goracle_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	goracle "gopkg.in/goracle.v2"
+)
+
+func TestPurity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	acquired := make(map[goracle.Purity]bool)
+	oldLog := goracle.Log
+	goracle.Log = func(kv ...interface{}) error {
+		if len(kv) < 2 || kv[1] != "acquireConn" {
+			if oldLog == nil {
+				return nil
+			}
+			return oldLog(kv...)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 2; i < len(kv)-1; i += 2 {
+			if kv[i] == "purity" {
+				acquired[kv[i+1].(goracle.Purity)] = true
+			}
+		}
+		t.Log(kv...)
+		return nil
+	}
+	defer func() { goracle.Log = oldLog }()
+
+	conn, err := testDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, purity := range []goracle.Purity{goracle.PurityNew, goracle.PuritySelf} {
+		pCtx := goracle.ContextWithConnClass(goracle.ContextWithPurity(ctx, purity), "GORACLE_TEST")
+		var n int
+		if err := conn.QueryRowContext(pCtx, "SELECT 1 FROM DUAL").Scan(&n); err != nil {
+			t.Fatalf("%s: %+v", purity, err)
+		}
+		mu.Lock()
+		ok := acquired[purity]
+		mu.Unlock()
+		if !ok {
+			t.Errorf("%s: session has not been re-acquired", purity)
+		}
+	}
+
+	// the session must not be swapped in a transaction
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(goracle.ContextWithPurity(ctx, goracle.PurityNew), "SELECT 1 FROM DUAL"); err == nil {
+		t.Error("session params changed in a transaction")
+	}
+}
+
+func TestSessionTag(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var fixups []string
+	cx, err := goracle.NewConnector(testConStr, nil, goracle.WithSessionFixup(
+		func(ctx context.Context, conn driver.Conn, requested, actual string) error {
+			mu.Lock()
+			fixups = append(fixups, actual+"->"+requested)
+			mu.Unlock()
+			// the tag is in the form of "key=value"
+			i := strings.IndexByte(requested, '=')
+			return goracle.NewSessionIniter(map[string]string{requested[:i]: requested[i+1:]})(conn)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(cx)
+	defer db.Close()
+
+	const tag = "NLS_DATE_FORMAT=YYYY-MM-DD"
+	tagCtx := goracle.ContextWithSessionTag(ctx, tag, true)
+	for i := 0; i < 2; i++ {
+		var format string
+		if err := db.QueryRowContext(tagCtx,
+			"SELECT value FROM nls_session_parameters WHERE parameter = 'NLS_DATE_FORMAT'",
+		).Scan(&format); err != nil {
+			t.Fatal(err)
+		}
+		if format != "YYYY-MM-DD" {
+			t.Errorf("%d. got date format %q, wanted YYYY-MM-DD", i, format)
+		}
+	}
+	mu.Lock()
+	t.Log("fixups:", fixups)
+	if len(fixups) == 0 {
+		t.Error("fixup has not been called")
+	}
+	mu.Unlock()
+}
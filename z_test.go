@@ -29,6 +29,7 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -1561,6 +1562,46 @@ func TestReturning(t *testing.T) {
 		t.Fatal(err)
 	}
 	t.Logf("RETURNING (zero set): %v", got)
+
+	// executeMany
+	ins := []string{"a", "b", "c"}
+	var gotMany []string
+	if _, err := testDb.Exec(
+		`INSERT INTO test_returning (a) VALUES (UPPER(:1)) RETURNING a INTO :2`,
+		ins, sql.Out{Dest: &gotMany},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B", "C"}; !reflect.DeepEqual(gotMany, want) {
+		t.Errorf("executeMany got %q, wanted %q", gotMany, want)
+	}
+
+	// multiple rows for one input: "A" has two rows, "X" has none.
+	if _, err := testDb.Exec(`INSERT INTO test_returning (a) VALUES ('A')`); err != nil {
+		t.Fatal(err)
+	}
+	upd := `UPDATE test_returning SET a = LOWER(a) WHERE a = :1 RETURNING a INTO :2`
+	var perIter [][]string
+	if _, err := testDb.Exec(upd, []string{"A", "X", "C"}, sql.Out{Dest: &perIter}); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"a", "a"}, nil, {"c"}}; !reflect.DeepEqual(perIter, want) {
+		t.Errorf("per iteration got %q, wanted %q", perIter, want)
+	}
+
+	var flat []string
+	rr := goracle.ReturnedRows{Dest: &flat}
+	if _, err := testDb.Exec(strings.Replace(upd, "LOWER", "UPPER", 1),
+		[]string{"a", "B", "x", "c"}, sql.Out{Dest: &rr},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "A", "B", "C"}; !reflect.DeepEqual(flat, want) {
+		t.Errorf("flat got %q, wanted %q", flat, want)
+	}
+	if want := []int{0, 2, 3, 3}; !reflect.DeepEqual(rr.Offsets, want) {
+		t.Errorf("offsets got %v, wanted %v", rr.Offsets, want)
+	}
 }
 
 func TestMaxOpenCursors(t *testing.T) {
@@ -2274,3 +2315,1222 @@ END;`
 		}
 	}
 }
+
+func TestBatchErrors(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tbl := "test_batch_errors" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+" (id NUMBER(3) PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+
+	ids := []int{1, 2, 2, 3, 1000, 4}
+	_, err := testDb.ExecContext(ctx, "INSERT INTO "+tbl+" (id) VALUES (:1)", ids, goracle.BatchErrors())
+	var be *goracle.BatchError
+	if !errors.As(err, &be) {
+		t.Fatalf("wanted BatchError, got %+v", err)
+	}
+	t.Log(be)
+	if be.RowsAffected != 4 {
+		t.Errorf("got %d rows affected, wanted 4", be.RowsAffected)
+	}
+	if len(be.Errors) != 2 {
+		t.Fatalf("got %d errors, wanted 2", len(be.Errors))
+	}
+	for i, want := range []struct{ Offset, Code int }{{2, 1}, {4, 1438}} {
+		if got := be.Errors[i]; got.Offset != want.Offset || got.Err.Code() != want.Code {
+			t.Errorf("%d. got %d:%v, wanted %d:ORA-%05d", i, got.Offset, got.Err, want.Offset, want.Code)
+		}
+	}
+
+	var n int
+	if err := testDb.QueryRowContext(ctx, "SELECT COUNT(0) FROM "+tbl).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("got %d rows in the table, wanted 4", n)
+	}
+}
+
+func TestArrayDMLRowCounts(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tbl := "test_rowcounts" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+" (id NUMBER(3), txt VARCHAR2(10))"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+	if _, err := testDb.ExecContext(ctx, "INSERT INTO "+tbl+" (id) VALUES (:1)", []int{1, 2, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	var counts []int64
+	res, err := testDb.ExecContext(ctx, "UPDATE "+tbl+" SET txt = :1 WHERE id = :2",
+		[]string{"a", "b", "c"}, []int{1, 2, 3}, goracle.ArrayDMLRowCounts(&counts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1, 2, 0}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("got %v, wanted %v", counts, want)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 3 {
+		t.Errorf("got %d (%+v), wanted 3 rows affected", n, err)
+	}
+}
+
+func TestScrollable(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := testDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err = goracle.QueryScrollable(ctx, conn,
+		"SELECT LEVEL FROM DUAL CONNECT BY LEVEL <= 10",
+		func(sc goracle.Scroller) error {
+			dest := make([]driver.Value, 1)
+			next := func(name string, want int64) {
+				t.Helper()
+				if err := sc.Next(dest); err != nil {
+					t.Fatalf("%s: %+v", name, err)
+				}
+				var n int64
+				switch x := dest[0].(type) {
+				case int64:
+					n = x
+				case goracle.Number:
+					n, _ = strconv.ParseInt(string(x), 10, 64)
+				default:
+					t.Fatalf("%s: got %T", name, dest[0])
+				}
+				if n != want {
+					t.Errorf("%s: got %d, wanted %d", name, n, want)
+				}
+			}
+			next("start", 1)
+			next("next", 2)
+			for _, tc := range []struct {
+				Name   string
+				Scroll func() error
+				Want   int64
+			}{
+				{"last", sc.Last, 10},
+				{"first", sc.First, 1},
+				{"absolute", func() error { return sc.Absolute(7) }, 7},
+				{"prior", sc.Prior, 6},
+				{"relative", func() error { return sc.Relative(3) }, 9},
+			} {
+				if err := tc.Scroll(); err != nil {
+					t.Fatalf("%s: %+v", tc.Name, err)
+				}
+				next(tc.Name, tc.Want)
+			}
+
+			// read till the end, then go back
+			for {
+				if err := sc.Next(dest); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("till EOF: %+v", err)
+				}
+			}
+			if err := sc.First(); err != nil {
+				t.Fatalf("first after EOF: %+v", err)
+			}
+			next("first after EOF", 1)
+			if err := sc.Absolute(5); err != nil {
+				t.Fatalf("absolute after EOF: %+v", err)
+			}
+			next("absolute after EOF", 5)
+			return nil
+		},
+		goracle.FetchRowCount(3),
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInRefCursor(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := testDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	const openQry = "BEGIN OPEN :1 FOR SELECT LEVEL FROM DUAL CONNECT BY LEVEL <= 5; END;"
+	const countQry = `DECLARE
+  v_cur SYS_REFCURSOR := :1;
+  v_n PLS_INTEGER;
+BEGIN
+  :2 := 0;
+  LOOP
+    FETCH v_cur INTO v_n;
+    EXIT WHEN v_cur%NOTFOUND;
+    :2 := :2 + v_n;
+  END LOOP;
+  CLOSE v_cur;
+END;`
+
+	var dr driver.Rows
+	if _, err = conn.ExecContext(ctx, openQry, sql.Out{Dest: &dr}); err != nil {
+		t.Fatal(err)
+	}
+	defer dr.Close()
+	var sum int64
+	if _, err = conn.ExecContext(ctx, countQry, dr, sql.Out{Dest: &sum}); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 15 {
+		t.Errorf("driver.Rows: got %d, wanted 15", sum)
+	}
+
+	var dr3 driver.Rows
+	if _, err = conn.ExecContext(ctx, "BEGIN OPEN :1 FOR SELECT LEVEL FROM DUAL CONNECT BY LEVEL <= 3; END;", sql.Out{Dest: &dr3}); err != nil {
+		t.Fatal(err)
+	}
+	defer dr3.Close()
+	cur, err := goracle.AsCursor(dr3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.ExecContext(ctx, countQry, cur, sql.Out{Dest: &sum}); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 6 {
+		t.Errorf("Cursor: got %d, wanted 6", sum)
+	}
+
+	var other driver.Rows
+	if _, err = testDb.ExecContext(ctx, openQry, sql.Out{Dest: &other}); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if _, err = conn.ExecContext(ctx, countQry, other, sql.Out{Dest: &sum}); err == nil {
+		t.Error("wanted error for a cursor of another connection")
+	} else {
+		t.Log(err)
+	}
+}
+
+func TestNestedCursor(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rows, err := testDb.QueryContext(ctx, `SELECT m.lvl, CURSOR(SELECT LEVEL FROM DUAL CONNECT BY LEVEL <= m.lvl)
+  FROM (SELECT LEVEL AS lvl FROM DUAL CONNECT BY LEVEL <= 3) m
+  ORDER BY m.lvl`, goracle.FetchRowCount(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var prev *sql.Rows
+	for rows.Next() {
+		var lvl int
+		var sub sql.Rows
+		if err := rows.Scan(&lvl, &sub); err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil {
+			if prev.Next() {
+				t.Error("previous nested cursor is still valid")
+			} else if err := prev.Err(); err == nil {
+				t.Error("wanted error from the previous nested cursor")
+			} else {
+				t.Log("previous:", err)
+			}
+		}
+		// read only the first row, leave the rest for the check above
+		if !sub.Next() {
+			t.Fatalf("%d: no rows: %+v", lvl, sub.Err())
+		}
+		var i int
+		if err := sub.Scan(&i); err != nil {
+			t.Fatal(err)
+		}
+		if i != 1 {
+			t.Errorf("%d: got %d, wanted 1", lvl, i)
+		}
+		prev = &sub
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRowid(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tbl := "test_rowid" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+" (id NUMBER(3))"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+	if _, err := testDb.ExecContext(ctx, "INSERT INTO "+tbl+" (id) VALUES (:1)", []int{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := testDb.QueryContext(ctx, `SELECT id, ROWID,
+  DBMS_ROWID.rowid_object(ROWID), DBMS_ROWID.rowid_relative_fno(ROWID),
+  DBMS_ROWID.rowid_block_number(ROWID), DBMS_ROWID.rowid_row_number(ROWID)
+  FROM `+tbl+" ORDER BY id", goracle.DecodeTypes(goracle.Rowid{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	rowids := make(map[int]goracle.Rowid)
+	for rows.Next() {
+		var id int
+		var rowid goracle.Rowid
+		var want goracle.RowidParts
+		if err := rows.Scan(&id, &rowid, &want.Object, &want.File, &want.Block, &want.Row); err != nil {
+			t.Fatal(err)
+		}
+		got, err := rowid.Decode()
+		if err != nil {
+			t.Fatalf("%d. %q: %+v", id, rowid, err)
+		}
+		if got != want {
+			t.Errorf("%d. %q: got %+v, wanted %+v", id, rowid, got, want)
+		}
+		rowids[id] = rowid
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// without DecodeTypes, the ROWID is returned as string
+	var s string
+	var b []byte
+	if err := testDb.QueryRowContext(ctx, "SELECT ROWID, ROWID FROM "+tbl+" WHERE id = 1").Scan(&s, &b); err != nil {
+		t.Fatal(err)
+	}
+	if s != rowids[1].String() || string(b) != s {
+		t.Errorf("got %q and %q, wanted %q", s, b, rowids[1])
+	}
+
+	for id, rowid := range rowids {
+		// the fetched ROWID is bound as is, the one made from text as string
+		for _, r := range []goracle.Rowid{rowid, goracle.NewRowid(rowid.String())} {
+			var got int
+			if err := testDb.QueryRowContext(ctx, "SELECT id FROM "+tbl+" WHERE ROWID = :1", r).Scan(&got); err != nil {
+				t.Fatalf("%q: %+v", r, err)
+			}
+			if got != id {
+				t.Errorf("%q: got %d, wanted %d", r, got, id)
+			}
+		}
+	}
+
+	var out goracle.Rowid
+	if _, err := testDb.ExecContext(ctx, "BEGIN SELECT ROWID INTO :1 FROM "+tbl+" WHERE id = 2; END;", //nolint:gas
+		sql.Out{Dest: &out},
+	); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if out.String() != rowids[2].String() {
+		t.Errorf("OUT: got %q, wanted %q", out, rowids[2])
+	}
+}
+
+func TestNullableBind(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	one, a := int64(1), "a"
+	day := time.Date(2019, 10, 18, 0, 0, 0, 0, time.Local)
+	for i, tc := range []struct {
+		Qry       string
+		Dest      interface{}
+		Want      interface{}
+		WantIsNil bool
+	}{
+		{Qry: ":x := :x + 1", Dest: &one, Want: int64(2)},
+		{Qry: ":x := :x + 1", Dest: new(*int64), WantIsNil: true},
+		{Qry: ":x := UPPER(:x)", Dest: &sql.NullString{String: "a", Valid: true}, Want: sql.NullString{String: "A", Valid: true}},
+		{Qry: ":x := UPPER(:x)", Dest: &sql.NullString{}, Want: sql.NullString{}},
+		{Qry: ":x := :x || 'b'", Dest: func() **string { p := &a; return &p }(), Want: "ab"},
+		{Qry: ":x := NOT :x", Dest: &sql.NullBool{Bool: true, Valid: true}, Want: sql.NullBool{Valid: true}},
+		{Qry: ":x := NOT :x", Dest: &sql.NullBool{}, Want: sql.NullBool{}},
+		{Qry: ":x := :x + 1", Dest: func() **time.Time { p := &day; return &p }(), Want: day.AddDate(0, 0, 1)},
+		{Qry: ":x := :x + 1", Dest: new(*time.Time), WantIsNil: true},
+		{Qry: ":x := :x * 2", Dest: func() *time.Duration { d := 90 * time.Minute; return &d }(), Want: 3 * time.Hour},
+		{Qry: ":x := :x * 2", Dest: new(*time.Duration), WantIsNil: true},
+		{Qry: ":x := :x + INTERVAL '1' YEAR", Dest: &goracle.IntervalYM{Years: 1, Months: 2}, Want: goracle.IntervalYM{Years: 2, Months: 2}},
+	} {
+		if _, err := testDb.ExecContext(ctx, "BEGIN "+tc.Qry+"; END;",
+			sql.Named("x", sql.Out{Dest: tc.Dest, In: true}),
+		); err != nil {
+			t.Errorf("%d. %s [%T]: %+v", i, tc.Qry, tc.Dest, err)
+			continue
+		}
+		got := reflect.ValueOf(tc.Dest).Elem()
+		if tc.WantIsNil {
+			if !got.IsNil() {
+				t.Errorf("%d. %s: got %v, wanted nil", i, tc.Qry, got.Elem())
+			}
+			continue
+		}
+		if got.Kind() == reflect.Ptr {
+			if got.IsNil() {
+				t.Errorf("%d. %s: got nil, wanted %v", i, tc.Qry, tc.Want)
+				continue
+			}
+			got = got.Elem()
+		}
+		if g := got.Interface(); !reflect.DeepEqual(g, tc.Want) {
+			if gt, ok := g.(time.Time); !ok || !gt.Equal(tc.Want.(time.Time)) {
+				t.Errorf("%d. %s: got %#v, wanted %#v", i, tc.Qry, g, tc.Want)
+			}
+		}
+	}
+
+	tbl := "test_nullable" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+" (id NUMBER(3), txt VARCHAR2(10), dt DATE)"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+	id := int64(1)
+	if _, err := testDb.ExecContext(ctx, "INSERT INTO "+tbl+" (id, txt, dt) VALUES (:1, :2, :3)",
+		[]*int64{&id, nil, &id},
+		[]sql.NullString{{String: a, Valid: true}, {}, {}},
+		[]*time.Time{nil, &day, nil},
+	); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := testDb.QueryRowContext(ctx,
+		"SELECT COUNT(0) FROM "+tbl+" WHERE (id = 1 AND txt = 'a' AND dt IS NULL) OR (id IS NULL AND txt IS NULL AND dt = :1) OR (id = 1 AND txt IS NULL AND dt IS NULL)",
+		day,
+	).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d rows, wanted 3", n)
+	}
+}
+
+func TestNullAsNil(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	P, err := goracle.ParseConnString(testConStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	P.NullAsNil = true
+	nilDb, err := sql.Open("goracle", P.StringWithPassword())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nilDb.Close()
+
+	types := []string{
+		"VARCHAR2(10)", "NVARCHAR2(10)", "CHAR(10)", "NCHAR(10)",
+		"NUMBER", "NUMBER(3)", "BINARY_FLOAT", "BINARY_DOUBLE",
+		"DATE", "TIMESTAMP", "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE",
+		"INTERVAL DAY TO SECOND", "INTERVAL YEAR TO MONTH",
+		"RAW(10)", "CLOB", "NCLOB", "BLOB", "ROWID",
+	}
+	cols := make([]string, len(types))
+	for i, typ := range types {
+		cols[i] = "CAST(NULL AS " + typ + ")"
+	}
+	qry := "SELECT " + strings.Join(cols, ", ") + " FROM DUAL"
+
+	for name, query := range map[string]func() (*sql.Rows, error){
+		"option": func() (*sql.Rows, error) { return testDb.QueryContext(ctx, qry, goracle.NullAsNil()) },
+		"lob": func() (*sql.Rows, error) {
+			return testDb.QueryContext(ctx, qry, goracle.NullAsNil(), goracle.LobAsReader())
+		},
+		"param": func() (*sql.Rows, error) { return nilDb.QueryContext(ctx, qry) },
+	} {
+		rows, err := query()
+		if err != nil {
+			t.Fatalf("%s: %+v", name, err)
+		}
+		dests := make([]interface{}, len(types))
+		ptrs := make([]interface{}, len(dests))
+		for i := range dests {
+			ptrs[i] = &dests[i]
+		}
+		if !rows.Next() {
+			rows.Close()
+			t.Fatalf("%s: no rows: %+v", name, rows.Err())
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			rows.Close()
+			t.Fatalf("%s: %+v", name, err)
+		}
+		rows.Close()
+		for i, v := range dests {
+			if v != nil {
+				t.Errorf("%s: %s: got %#v, wanted nil", name, types[i], v)
+			}
+		}
+	}
+
+	// the default is unchanged
+	var s interface{}
+	if err := testDb.QueryRowContext(ctx, "SELECT CAST(NULL AS VARCHAR2(10)) FROM DUAL").Scan(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s != "" {
+		t.Errorf("got %#v, wanted the empty string", s)
+	}
+}
+
+func TestAs(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tbl := "test_as" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+" (f_id NUMBER(3), f_clob CLOB)"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+
+	long := strings.Repeat("árvíztűrő tükörfúrógép ", 100000/23)
+	ids := []int{1, 2, 3}
+	clobs := []string{"a", long, ""}
+	if _, err := testDb.ExecContext(ctx,
+		"INSERT INTO "+tbl+" (f_id, f_clob) VALUES (:1, :2)", //nolint:gas
+		ids, goracle.As(clobs, goracle.TypeClob),
+	); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var got string
+	if err := testDb.QueryRowContext(ctx,
+		"SELECT f_clob FROM "+tbl+" WHERE f_id = 2", //nolint:gas
+	).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != long {
+		t.Errorf("got %d, wanted %d bytes", len(got), len(long))
+	}
+
+	var out string
+	if _, err := testDb.ExecContext(ctx,
+		"DECLARE v_clob CLOB; BEGIN SELECT f_clob INTO v_clob FROM "+tbl+" WHERE f_id = 2; :1 := v_clob; END;", //nolint:gas
+		sql.Out{Dest: goracle.As(&out, goracle.TypeClob)},
+	); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if out != long {
+		t.Errorf("OUT: got %d, wanted %d bytes", len(out), len(long))
+	}
+}
+
+type testUUID [16]byte
+
+func TestRegisterType(t *testing.T) {
+	// not parallel, as the registration changes how all testUUID values are bound
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := goracle.RegisterType(testUUID{}, goracle.TypeRaw,
+		func(v interface{}) (interface{}, error) {
+			u := v.(testUUID)
+			return u[:], nil
+		},
+		func(v interface{}) (interface{}, error) {
+			var u testUUID
+			if b := v.([]byte); len(b) != len(u) {
+				return nil, errors.Errorf("got %d bytes, wanted %d", len(b), len(u))
+			}
+			copy(u[:], v.([]byte))
+			return u, nil
+		},
+	); err != nil {
+		t.Fatal(err)
+	}
+	defer goracle.UnregisterType(testUUID{})
+
+	tbl := "test_regtype" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+" (f_id NUMBER(3), f_uuid RAW(16))"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+
+	uuids := make([]testUUID, 3)
+	ids := make([]int, len(uuids))
+	for i := range uuids {
+		ids[i] = i
+		rand.Read(uuids[i][:])
+	}
+	if _, err := testDb.ExecContext(ctx,
+		"INSERT INTO "+tbl+" (f_id, f_uuid) VALUES (:1, :2)", //nolint:gas
+		ids, uuids,
+	); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var raw []byte
+	if err := testDb.QueryRowContext(ctx, "SELECT f_uuid FROM "+tbl+" WHERE f_id = 1", //nolint:gas
+	).Scan(&raw); err != nil {
+		t.Fatalf("without DecodeTypes: %+v", err)
+	}
+	if !bytes.Equal(raw, uuids[1][:]) {
+		t.Errorf("without DecodeTypes: got %x, wanted %x", raw, uuids[1])
+	}
+
+	rows, err := testDb.QueryContext(ctx, "SELECT f_id, f_uuid FROM "+tbl+" ORDER BY f_id", //nolint:gas
+		goracle.DecodeTypes(testUUID{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if cts, err := rows.ColumnTypes(); err != nil {
+		t.Fatal(err)
+	} else if st := cts[1].ScanType(); st != reflect.TypeOf(testUUID{}) {
+		t.Errorf("scan type: got %v, wanted testUUID", st)
+	}
+	for rows.Next() {
+		var id int
+		var u testUUID
+		if err := rows.Scan(&id, &u); err != nil {
+			t.Fatal(err)
+		}
+		if u != uuids[id] {
+			t.Errorf("%d. got %x, wanted %x", id, u, uuids[id])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var u testUUID
+	if _, err := testDb.ExecContext(ctx,
+		"BEGIN SELECT f_uuid INTO :1 FROM "+tbl+" WHERE f_id = 1; END;", //nolint:gas
+		sql.Out{Dest: &u},
+	); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if u != uuids[1] {
+		t.Errorf("OUT: got %x, wanted %x", u, uuids[1])
+	}
+}
+
+func TestNativeNumbers(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	const qry = `SELECT CAST(12 AS NUMBER(9)), CAST(1.5 AS FLOAT(10)),
+		CAST(2.5 AS BINARY_FLOAT), CAST(3.5 AS BINARY_DOUBLE),
+		CAST(4.25 AS NUMBER(5,2)), CAST(5 AS NUMBER) FROM DUAL`
+	want := []interface{}{int64(12), float64(1.5), float64(2.5), float64(3.5), goracle.Number("4.25"), goracle.Number("5")}
+	rows, err := testDb.QueryContext(ctx, qry, goracle.NativeNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]interface{}, len(want))
+	ptrs := make([]interface{}, len(got))
+	for i := range got {
+		ptrs[i] = &got[i]
+		if st, wt := cts[i].ScanType(), reflect.TypeOf(want[i]); st != wt {
+			t.Errorf("%d. scan type: got %v, wanted %v", i, st, wt)
+		}
+	}
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	if err = rows.Scan(ptrs...); err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d. got %T(%v), wanted %T(%v)", i, got[i], got[i], want[i], want[i])
+		}
+	}
+}
+
+func TestTimeZoneRegions(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	budapest, err := time.LoadLocation("Europe/Budapest")
+	if err != nil {
+		t.Skip(err)
+	}
+	conn, err := testDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	want := time.Date(2019, 7, 1, 12, 30, 0, 0, budapest)
+	var got time.Time
+	if err = conn.QueryRowContext(ctx,
+		"SELECT FROM_TZ(TIMESTAMP '2019-07-01 12:30:00', 'Europe/Budapest') FROM DUAL",
+		goracle.TimeZoneRegions(),
+	).Scan(&got); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !got.Equal(want) || got.Location().String() != "Europe/Budapest" {
+		t.Errorf("got %v (%s), wanted %v", got, got.Location(), want)
+	}
+
+	var region string
+	if err = conn.QueryRowContext(ctx,
+		"SELECT TO_CHAR(CAST(:1 AS TIMESTAMP WITH TIME ZONE), 'TZR') FROM DUAL",
+		want, goracle.TimeZoneRegions(),
+	).Scan(&region); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !strings.EqualFold(region, "Europe/Budapest") {
+		t.Errorf("bound region: got %q, wanted Europe/Budapest", region)
+	}
+
+	// DATE values in the given time zone
+	tz := time.FixedZone("X", -5*3600)
+	if err = conn.QueryRowContext(goracle.ContextWithTimeZone(ctx, tz),
+		"SELECT TO_DATE('2019-07-01 12:30:00', 'YYYY-MM-DD HH24:MI:SS') FROM DUAL",
+	).Scan(&got); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := time.Date(2019, 7, 1, 12, 30, 0, 0, tz); !got.Equal(want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestNString(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := testDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	dc, err := goracle.DriverConn(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ei, err := dc.EncodingInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("encoding: %+v", ei)
+	if ei.DBCharset == "" || ei.DBNCharset == "" {
+		t.Errorf("no database character sets in %+v", ei)
+	}
+
+	tbl := "test_nstring" + tblSuffix
+	conn.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err = conn.ExecContext(ctx, "CREATE TABLE "+tbl+" (id NUMBER(3), nv NVARCHAR2(100), nc NCLOB)"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+
+	const want = "árvíztűrő tükörfúrógép ΑΒΓ 日本語"
+	if _, err = conn.ExecContext(ctx, "INSERT INTO "+tbl+" (id, nv, nc) VALUES (:1, :2, :3)",
+		[]int{1, 2}, []goracle.NString{want, ""},
+		[]goracle.Lob{{Reader: strings.NewReader(want), IsNClob: true}, {IsNClob: true}},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var got goracle.NString
+	if _, err = conn.ExecContext(ctx, "BEGIN SELECT nv INTO :1 FROM "+tbl+" WHERE id = :2; END;",
+		sql.Out{Dest: &got}, 1,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("OUT: got %q, wanted %q", got, want)
+	}
+
+	var n int
+	if err = conn.QueryRowContext(ctx, "SELECT COUNT(0) FROM "+tbl+" WHERE nv = :1", goracle.NString(want)).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("found %d rows for %q, wanted 1", n, want)
+	}
+
+	var s, c string
+	if err = conn.QueryRowContext(ctx, "SELECT nv, nc FROM "+tbl+" WHERE id = 1", goracle.ClobAsString()).Scan(&s, &c); err != nil {
+		t.Fatal(err)
+	}
+	if s != want || c != want {
+		t.Errorf("got %q and %q, wanted %q", s, c, want)
+	}
+}
+
+func TestXML(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tbl := "test_xml" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+" (id NUMBER(3), doc XMLTYPE)"); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+
+	const short = `<person id="1"><name>Short</name></person>`
+	long := `<person id="2"><name>` + strings.Repeat("Long", 10000) + `</name></person>`
+	if _, err := testDb.ExecContext(ctx, "INSERT INTO "+tbl+" (id, doc) VALUES (:1, :2)", 1, short); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDb.ExecContext(ctx, "INSERT INTO "+tbl+" (id, doc) VALUES (:1, :2)", 2, goracle.XML(long)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDb.ExecContext(ctx, "BEGIN INSERT INTO "+tbl+" (id, doc) VALUES (:1, XMLType(:2)); END;",
+		3, goracle.XML(short),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := testDb.QueryContext(ctx, "SELECT id, doc FROM "+tbl+" ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cts[1].DatabaseTypeName(); got != "XMLTYPE" {
+		t.Errorf("database type name: got %q, wanted XMLTYPE", got)
+	}
+	for rows.Next() {
+		var id int
+		var x goracle.XML
+		if err = rows.Scan(&id, &x); err != nil {
+			t.Fatal(err)
+		}
+		var p struct {
+			ID   int    `xml:"id,attr"`
+			Name string `xml:"name"`
+		}
+		if err = x.Unmarshal(&p); err != nil {
+			t.Errorf("%d. %q: %+v", id, x, err)
+			continue
+		}
+		wantID, wantLen := 1, len("Short")
+		if id == 2 {
+			wantID, wantLen = 2, 40000
+		}
+		if p.ID != wantID || len(p.Name) != wantLen {
+			t.Errorf("%d. got %d with name of length %d, wanted %d and %d", id, p.ID, len(p.Name), wantID, wantLen)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tbl := "test_json" + tblSuffix
+	testDb.ExecContext(ctx, "DROP TABLE "+tbl)
+	if _, err := testDb.ExecContext(ctx, "CREATE TABLE "+tbl+` (id NUMBER(3),
+  vc VARCHAR2(1000) CHECK (vc IS JSON), cl CLOB CHECK (cl IS JSON), bl BLOB CHECK (bl IS JSON))`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	defer testDb.Exec("DROP TABLE " + tbl)
+
+	type doc struct {
+		Amount goracle.Number `json:"amount"`
+		Text   string         `json:"text"`
+	}
+	small := doc{Amount: "12345678901234567890.123456789", Text: "small"}
+	large := doc{Amount: "-1.5", Text: strings.Repeat("large", 10000)}
+	if _, err := testDb.ExecContext(ctx, "INSERT INTO "+tbl+" (id, vc, cl, bl) VALUES (:1, :2, :3, :4)",
+		[]int{1, 2},
+		[]goracle.JSON{{Value: small}, {}},
+		[]goracle.JSON{{Value: small}, {Value: large}},
+		[]goracle.JSON{{Value: small, IsBlob: true}, {Value: large, IsBlob: true}},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opt := range []goracle.Option{goracle.ClobAsString(), goracle.LobAsReader()} {
+		rows, err := testDb.QueryContext(ctx, "SELECT id, vc, cl, bl FROM "+tbl+" ORDER BY id", opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var id int
+			var vc, cl, bl doc
+			if err = rows.Scan(&id, &goracle.JSON{Value: &vc}, &goracle.JSON{Value: &cl}, &goracle.JSON{Value: &bl}); err != nil {
+				rows.Close()
+				t.Fatal(err)
+			}
+			want := small
+			if id == 2 {
+				want = large
+				if vc != (doc{}) {
+					t.Errorf("%d. NULL: got %+v", id, vc)
+				}
+				vc = want
+			}
+			for _, got := range []doc{vc, cl, bl} {
+				if got.Amount != want.Amount || got.Text != want.Text {
+					t.Errorf("%d. got %q/%d, wanted %q/%d", id, got.Amount, len(got.Text), want.Amount, len(want.Text))
+				}
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSoda(t *testing.T) {
+	if clientVersion.Version < 18 || serverVersion.Version < 18 {
+		t.Skipf("SODA needs client and server 18, have %d and %d", clientVersion.Version, serverVersion.Version)
+	}
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	conn, err := testDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	dc, err := goracle.DriverConn(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := dc.SodaDB()
+	if err != nil {
+		t.Skip(err)
+	}
+	defer db.Close()
+
+	name := "test_soda" + tblSuffix
+	if _, err = db.OpenCollection(ctx, name); !errors.Is(err, goracle.ErrSodaNoCollection) {
+		t.Errorf("open non-existing: got %v, wanted ErrSodaNoCollection", err)
+	}
+	coll, err := db.CreateCollection(ctx, name, "")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer func() {
+		if dropped, err := coll.Drop(context.Background()); err != nil || !dropped {
+			t.Errorf("drop: %t %v", dropped, err)
+		}
+		coll.Close()
+	}()
+	names, err := db.CollectionNames(ctx, name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != name {
+		t.Errorf("names: got %q, wanted %q", names, name)
+	}
+
+	type person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	alice, err := coll.InsertOne(ctx, goracle.SodaDocument{Content: []byte(`{"name":"Alice","age":30}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Key == "" || alice.Version == "" {
+		t.Errorf("inserted: %+v", alice)
+	}
+	if _, err = coll.InsertMany(ctx, []goracle.SodaDocument{
+		{Content: []byte(`{"name":"Bob","age":40}`)},
+		{Content: []byte(`{"name":"Cecil","age":50}`)},
+	}); err != nil {
+		t.Log(err) // needs client 18.5
+		for _, c := range []string{`{"name":"Bob","age":40}`, `{"name":"Cecil","age":50}`} {
+			if _, err = coll.InsertOne(ctx, goracle.SodaDocument{Content: []byte(c)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if n, err := coll.Count(ctx, goracle.SodaOperOptions{}); err != nil || n != 3 {
+		t.Errorf("count: got %d (%v), wanted 3", n, err)
+	}
+	doc, ok, err := coll.FindOne(ctx, goracle.SodaOperOptions{Key: alice.Key})
+	if err != nil || !ok {
+		t.Fatalf("findOne %q: %t %v", alice.Key, ok, err)
+	}
+	var p person
+	if err = doc.Unmarshal(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Alice" {
+		t.Errorf("findOne: got %+v", p)
+	}
+
+	cur, err := coll.Find(ctx, goracle.SodaOperOptions{Filter: `{"age": {"$gt": 35}}`, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for {
+		doc, err := cur.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			cur.Close()
+			t.Fatal(err)
+		}
+		if err = doc.Unmarshal(&p); err != nil {
+			t.Fatal(err)
+		}
+		found = append(found, p.Name)
+	}
+	cur.Close()
+	sort.Strings(found)
+	if strings.Join(found, ",") != "Bob,Cecil" {
+		t.Errorf("find: got %q", found)
+	}
+
+	replaced, newDoc, err := coll.ReplaceOne(ctx, goracle.SodaOperOptions{Key: alice.Key, Version: alice.Version},
+		goracle.SodaDocument{Content: []byte(`{"name":"Alice","age":31}`)})
+	if err != nil || !replaced {
+		t.Fatalf("replace: %t %v", replaced, err)
+	}
+	if newDoc.Version == alice.Version {
+		t.Errorf("replace: version not changed: %+v", newDoc)
+	}
+	// the old version does not match anymore
+	if replaced, _, err = coll.ReplaceOne(ctx, goracle.SodaOperOptions{Key: alice.Key, Version: alice.Version},
+		goracle.SodaDocument{Content: []byte(`{"name":"Alice","age":32}`)}); err != nil || replaced {
+		t.Errorf("replace old version: %t %v", replaced, err)
+	}
+
+	if err = coll.CreateIndex(ctx, `{"name": "`+name+`_AGE_IDX", "fields": [{"path": "age", "datatype": "number"}]}`); err != nil {
+		t.Fatal(err)
+	}
+	if dropped, err := coll.DropIndex(ctx, name+"_AGE_IDX", false); err != nil || !dropped {
+		t.Errorf("dropIndex: %t %v", dropped, err)
+	}
+	if err = coll.CreateIndex(ctx, `{"name": "`+name+`_SEARCH_IDX"}`); err != nil {
+		t.Log(err)
+	} else if guide, err := coll.DataGuide(ctx); err != nil {
+		t.Error(err)
+	} else {
+		t.Logf("data guide: %s", guide.Content)
+	}
+
+	if n, err := coll.Remove(ctx, goracle.SodaOperOptions{Filter: `{"name": "Bob"}`}); err != nil || n != 1 {
+		t.Errorf("remove: got %d (%v), wanted 1", n, err)
+	}
+	if n, err := coll.Count(ctx, goracle.SodaOperOptions{}); err != nil || n != 2 {
+		t.Errorf("count after remove: got %d (%v), wanted 2", n, err)
+	}
+}
+
+func TestSDOGeometry(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	const qry = `SELECT
+	MDSYS.SDO_GEOMETRY(2003, 8307, NULL,
+		MDSYS.SDO_ELEM_INFO_ARRAY(1,1003,1, 11,2003,1),
+		MDSYS.SDO_ORDINATE_ARRAY(0,0, 10,0, 10,10, 0,10, 0,0, 2,2, 2,4, 4,4, 4,2, 2,2)),
+	MDSYS.SDO_GEOMETRY(3001, NULL, MDSYS.SDO_POINT_TYPE(1, 2, 3), NULL, NULL),
+	CAST(NULL AS MDSYS.SDO_GEOMETRY)
+  FROM DUAL`
+	var poly, point, null goracle.Geometry
+	if err := testDb.QueryRowContext(ctx, qry).Scan(&poly, &point, &null); err != nil {
+		if strings.Contains(err.Error(), "ORA-00904:") || strings.Contains(err.Error(), "ORA-00902:") {
+			t.Skip(err)
+		}
+		t.Fatal(errors.Errorf("%s: %w", qry, err))
+	}
+	for _, tc := range []struct {
+		Got  goracle.Geometry
+		Want string
+	}{
+		{poly, "SRID=8307;POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 4, 4 4, 4 2, 2 2))"},
+		{point, "POINT Z (1 2 3)"},
+	} {
+		if got, err := tc.Got.WKT(); err != nil || got != tc.Want {
+			t.Errorf("got %q (%v), wanted %q", got, err, tc.Want)
+		}
+	}
+	if !null.IsNull() {
+		t.Errorf("NULL: got %+v", null)
+	}
+
+	const plsql = `DECLARE
+  v_geom MDSYS.SDO_GEOMETRY := :1;
+BEGIN
+  :2 := DBMS_LOB.SUBSTR(SDO_UTIL.TO_WKTGEOMETRY(v_geom), 32767);
+  :3 := v_geom;
+END;`
+	stmt, err := testDb.PrepareContext(ctx, plsql)
+	if err != nil {
+		t.Fatal(errors.Errorf("%s: %w", plsql, err))
+	}
+	defer stmt.Close()
+	for _, wkt := range []string{
+		"SRID=4326;POINT (19.05 47.5)",
+		"LINESTRING (0 0, 1 1, 2 0)",
+		"POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 4, 4 4, 4 2, 2 2))",
+		"MULTIPOINT ((1 2), (3 4))",
+		"MULTILINESTRING Z ((0 0 1, 1 1 1), (2 2 2, 3 3 2))",
+		"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), ((5 5, 9 5, 9 9, 5 9, 5 5), (6 6, 6 7, 7 7, 6 6)))",
+		"GEOMETRYCOLLECTION (POINT (1 2), LINESTRING (0 0, 1 1))",
+	} {
+		in, err := goracle.ParseWKT(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var text string
+		var out goracle.Geometry
+		if _, err = stmt.ExecContext(ctx, in, sql.Out{Dest: &text}, sql.Out{Dest: &out}); err != nil {
+			t.Fatal(errors.Errorf("%s: %w", wkt, err))
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("%s: got %+v, wanted %+v", wkt, out, in)
+		}
+		// TO_WKTGEOMETRY does not return the SRID
+		fromDB, err := goracle.ParseWKT(text)
+		if err != nil {
+			t.Fatal(errors.Errorf("%s: %w", text, err))
+		}
+		fromDB.SRID = in.SRID
+		if !reflect.DeepEqual(fromDB, in) {
+			t.Errorf("%s: database says %q", wkt, text)
+		}
+	}
+}
+
+func TestInList(t *testing.T) {
+	t.Parallel()
+	defer tl.enableLogging(t)()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	const qry = `SELECT LEVEL FROM DUAL
+  WHERE LEVEL IN (SELECT column_value FROM TABLE(:1))
+  CONNECT BY LEVEL <= 10
+  ORDER BY 1`
+	stmt, err := testDb.PrepareContext(ctx, qry)
+	if err != nil {
+		t.Fatal(errors.Errorf("%s: %w", qry, err))
+	}
+	defer stmt.Close()
+	for _, tc := range []struct {
+		In   []int64
+		Want []int64
+	}{
+		{In: []int64{3, 1, 12, 7}, Want: []int64{1, 3, 7}},
+		{In: []int64{10}, Want: []int64{10}},
+		{In: []int64{}, Want: nil},
+	} {
+		rows, err := stmt.QueryContext(ctx, goracle.InList(tc.In))
+		if err != nil {
+			t.Fatal(errors.Errorf("%v: %w", tc.In, err))
+		}
+		var got []int64
+		for rows.Next() {
+			var i int64
+			if err = rows.Scan(&i); err != nil {
+				break
+			}
+			got = append(got, i)
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+		rows.Close()
+		if err != nil {
+			t.Fatal(errors.Errorf("%v: %w", tc.In, err))
+		}
+		if !reflect.DeepEqual(got, tc.Want) {
+			t.Errorf("%v: got %v, wanted %v", tc.In, got, tc.Want)
+		}
+	}
+
+	day := time.Date(2019, 10, 18, 0, 0, 0, 0, time.Local)
+	for _, tc := range []struct {
+		In   goracle.SQLCollection
+		Want string
+	}{
+		{goracle.InList([]string{"b", "a", "c"}), "a,b,c"},
+		{goracle.InList([]*string{nil}), ""},
+		{goracle.InList([]time.Time{day}), "2019-10-18"},
+		{goracle.Collection(goracle.ODCINumberList, []goracle.Number{"1.5", "-2"}), "-2,1.5"},
+	} {
+		var sub string
+		switch tc.In.Values.(type) {
+		case []time.Time:
+			sub = "SELECT TO_CHAR(column_value, 'YYYY-MM-DD') x FROM TABLE(:1)"
+		case []goracle.Number:
+			sub = "SELECT TO_CHAR(column_value, 'TM9') x FROM TABLE(:1)"
+		default:
+			sub = "SELECT column_value x FROM TABLE(:1)"
+		}
+		listQry := "SELECT LISTAGG(x, ',') WITHIN GROUP (ORDER BY x) FROM (" + sub + ")"
+		var got sql.NullString
+		if err := testDb.QueryRowContext(ctx, listQry, tc.In).Scan(&got); err != nil {
+			t.Fatal(errors.Errorf("%s: %w", listQry, err))
+		}
+		if got.String != tc.Want {
+			t.Errorf("%v: got %q, wanted %q", tc.In, got.String, tc.Want)
+		}
+	}
+
+	if _, err := testDb.ExecContext(ctx, "DECLARE v SYS.ODCINUMBERLIST; BEGIN :1 := v; END;", sql.Out{Dest: &goracle.SQLCollection{}}); err == nil {
+		t.Error("OUT SQLCollection succeeded")
+	}
+}